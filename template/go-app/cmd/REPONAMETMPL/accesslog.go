@@ -0,0 +1,138 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/router"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// AccessLogConfig defines the configuration for access logging.
+type AccessLogConfig struct {
+	// Minimum level of access logs of routes without override
+	Level slog.Level
+	// Minimum level of access logs per route pattern (e.g. "GET /foo/{bar}"), overriding Level
+	RouteLevels map[string]slog.Level
+	// Logger to log to, nil uses package logger
+	Logger *slog.Logger
+}
+
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	size       int64
+}
+
+func (w *accessLogResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Flush implements [net/http.Flusher].
+func (w *accessLogResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError flushes the underlying writer, it is used by [net/http.ResponseController], which thus gets
+// [net/http.ErrNotSupported] if underlying writer can't flush.
+func (w *accessLogResponseWriter) FlushError() error {
+	// Left unwrapped, as callers check for http.ErrNotSupported
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying [net/http.ResponseWriter].
+func (w *accessLogResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// accessLogLevel returns the level of an access log for a response of statusCode.
+func accessLogLevel(statusCode int) slog.Level {
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return slog.LevelError
+	case statusCode >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewAccessLogMiddleware returns a middleware logging requests served by mux. Access logs are logged at info
+// level, or warn and error for client and server errors, and are dropped below the minimum level of their
+// route, allowing to silence noisy routes (e.g. probes). Routes logging at debug level also log request
+// details, at the level of their status so that logger level does not filter them out, allowing to troubleshoot
+// a route without flooding logs of others.
+// Note that patterns are resolved against mux, hence RouteLevels keys must be patterns registered on it.
+func NewAccessLogMiddleware(mux *router.Router, conf AccessLogConfig) func(http.Handler) http.Handler {
+	logger := conf.Logger
+	if logger == nil {
+		logger = log.Logger(packageName)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := mux.Handler(r)
+
+			minLevel, ok := conf.RouteLevels[pattern]
+			if !ok {
+				minLevel = conf.Level
+			}
+
+			start := time.Now()
+			aw := &accessLogResponseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(aw, r)
+
+			if aw.statusCode == 0 {
+				aw.statusCode = http.StatusOK
+			}
+
+			level := accessLogLevel(aw.statusCode)
+			if level < minLevel {
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String(string(semconv.HTTPRequestMethodKey), r.Method),
+				slog.String(string(semconv.HTTPRouteKey), pattern),
+				slog.String(string(semconv.URLPathKey), r.URL.Path),
+				slog.Int(string(semconv.HTTPResponseStatusCodeKey), aw.statusCode),
+				slog.Duration("http.server.request.duration", time.Since(start)),
+			}
+
+			if minLevel <= slog.LevelDebug {
+				attrs = append(
+					attrs,
+					slog.String(string(semconv.URLQueryKey), r.URL.RawQuery),
+					slog.String(string(semconv.UserAgentOriginalKey), r.UserAgent()),
+					slog.String(string(semconv.ClientAddressKey), r.RemoteAddr),
+					slog.Int64(string(semconv.HTTPResponseBodySizeKey), aw.size),
+				)
+			}
+
+			logger.LogAttrs(r.Context(), level, "request served", attrs...)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/router"
+)
+
+func TestAccessLogMiddlewareRouteLevels(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	r := router.New()
+	r.Use(NewAccessLogMiddleware(r, AccessLogConfig{
+		Level: slog.LevelInfo,
+		RouteLevels: map[string]slog.Level{
+			"GET /noisy":          slog.LevelWarn,
+			"GET /debug/{id}":     slog.LevelDebug,
+			"GET /debug/critical": slog.LevelError,
+		},
+		// Filtered as in normal runtime, debug logs would be dropped
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	}))
+
+	for _, pattern := range []string{"GET /noisy", "GET /debug/{id}", "GET /debug/critical", "GET /other"} {
+		r.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Has("fail") {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+	}
+
+	tests := []struct {
+		Name   string
+		Target string
+		// Expected level of access log, empty if dropped
+		ExpectedLevel   string
+		ExpectedDetails bool
+	}{
+		{Name: "default", Target: "/other", ExpectedLevel: "INFO"},
+		{Name: "default error", Target: "/other?fail", ExpectedLevel: "ERROR"},
+		{Name: "silenced", Target: "/noisy"},
+		{Name: "silenced error", Target: "/noisy?fail", ExpectedLevel: "ERROR"},
+		{Name: "debug", Target: "/debug/1", ExpectedLevel: "INFO", ExpectedDetails: true},
+		{Name: "debug error", Target: "/debug/1?fail", ExpectedLevel: "ERROR", ExpectedDetails: true},
+		{Name: "more specific route", Target: "/debug/critical"},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, test.Target, nil)
+		req.Header.Set("User-Agent", "access-log-test")
+
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		line := buf.String()
+
+		if test.ExpectedLevel == "" {
+			if line != "" {
+				t.Errorf("%s: expected no access log; got %q", test.Name, line)
+			}
+
+			continue
+		}
+
+		if !strings.Contains(line, "level="+test.ExpectedLevel+" ") {
+			t.Errorf("%s: expected level %s; got %q", test.Name, test.ExpectedLevel, line)
+		}
+
+		if details := strings.Contains(line, "access-log-test"); details != test.ExpectedDetails {
+			t.Errorf("%s: expected details %t; got %q", test.Name, test.ExpectedDetails, line)
+		}
+	}
+}
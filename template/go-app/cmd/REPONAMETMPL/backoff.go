@@ -0,0 +1,112 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/failsafe-go/failsafe-go/retrypolicy"
+)
+
+// ResettingBackoffConfig defines the configuration for resetting backoff.
+type ResettingBackoffConfig struct {
+	// Delay before first retry, doubled on each subsequent failure
+	Delay time.Duration
+	// Maximum delay between retries, which should be below request timeout so that retries can complete
+	MaxDelay time.Duration
+	// Duration of uninterrupted success after which backoff is reset to Delay
+	ResetWindow time.Duration
+}
+
+// ResettingBackoff is an exponential backoff shared across executions of a retry policy, so that a failing
+// dependency is retried with increasing delays even by new executions. It should thus be used for a single
+// dependency. Failures occurring within the current delay, e.g. of concurrent executions, count as one, so
+// that a burst of failures does not jump straight to [ResettingBackoffConfig.MaxDelay]. Backoff is reset once
+// the dependency succeeded for [ResettingBackoffConfig.ResetWindow] without failure, so that a recovered
+// dependency does not keep long backoffs. It is safe for concurrent use.
+type ResettingBackoff[R any] struct {
+	conf         ResettingBackoffConfig
+	mu           sync.Mutex
+	failures     int
+	escalatedAt  time.Time
+	successSince time.Time
+	now          func() time.Time
+}
+
+// NewResettingBackoff returns a resetting backoff.
+func NewResettingBackoff[R any](conf ResettingBackoffConfig) *ResettingBackoff[R] {
+	return &ResettingBackoff[R]{
+		conf: conf,
+		now:  time.Now,
+	}
+}
+
+// Configure configures builder to use b as its delay, returning builder.
+func (b *ResettingBackoff[R]) Configure(builder retrypolicy.Builder[R]) retrypolicy.Builder[R] {
+	return builder.
+		WithDelayFunc(b.Delay).
+		OnSuccess(func(failsafe.ExecutionEvent[R]) {
+			b.RecordSuccess()
+		})
+}
+
+// resetIfRecovered resets backoff if success streak lasted for reset window. b.mu must be held.
+func (b *ResettingBackoff[R]) resetIfRecovered(now time.Time) {
+	if b.failures > 0 && !b.successSince.IsZero() && now.Sub(b.successSince) >= b.conf.ResetWindow {
+		b.failures = 0
+	}
+}
+
+// delay returns the delay after failures consecutive failures.
+func (b *ResettingBackoff[R]) delay(failures int) time.Duration {
+	delay := min(b.conf.Delay, b.conf.MaxDelay)
+
+	for range failures - 1 {
+		delay *= 2
+		if delay >= b.conf.MaxDelay {
+			return b.conf.MaxDelay
+		}
+	}
+
+	return delay
+}
+
+// Delay records a failed attempt and returns the delay before retrying it. It satisfies [failsafe.DelayFunc].
+func (b *ResettingBackoff[R]) Delay(_ failsafe.ExecutionAttempt[R]) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	b.resetIfRecovered(now)
+
+	// Failure breaks success streak
+	b.successSince = time.Time{}
+
+	// Only failures after current delay elapsed escalate backoff
+	if b.failures == 0 || now.Sub(b.escalatedAt) >= b.delay(b.failures) {
+		b.failures++
+		b.escalatedAt = now
+	}
+
+	return b.delay(b.failures)
+}
+
+// RecordSuccess records a successful execution.
+func (b *ResettingBackoff[R]) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	if b.successSince.IsZero() {
+		b.successSince = now
+	}
+
+	b.resetIfRecovered(now)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestBackoff(conf ResettingBackoffConfig) (*ResettingBackoff[any], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	b := NewResettingBackoff[any](conf)
+	b.now = clock.Now
+
+	return b, clock
+}
+
+func TestResettingBackoffDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name string
+		Conf ResettingBackoffConfig
+		// Whether delay elapses between failures, that is whether they are sequential rather than concurrent
+		Sequential     bool
+		ExpectedDelays []time.Duration
+	}{
+		{
+			Name:       "sequential failures",
+			Conf:       ResettingBackoffConfig{Delay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond},
+			Sequential: true,
+			ExpectedDelays: []time.Duration{
+				10 * time.Millisecond,
+				20 * time.Millisecond,
+				40 * time.Millisecond,
+				50 * time.Millisecond,
+				50 * time.Millisecond,
+			},
+		},
+		{
+			Name: "concurrent failures",
+			Conf: ResettingBackoffConfig{Delay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond},
+			ExpectedDelays: []time.Duration{
+				10 * time.Millisecond,
+				10 * time.Millisecond,
+				10 * time.Millisecond,
+			},
+		},
+		{
+			Name:       "delay above maximum",
+			Conf:       ResettingBackoffConfig{Delay: time.Second, MaxDelay: 50 * time.Millisecond},
+			Sequential: true,
+			ExpectedDelays: []time.Duration{
+				50 * time.Millisecond,
+				50 * time.Millisecond,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, clock := newTestBackoff(test.Conf)
+
+		for i, expected := range test.ExpectedDelays {
+			delay := b.Delay(nil)
+			if delay != expected {
+				t.Errorf("%s: failure %d: expected delay %s; got %s", test.Name, i, expected, delay)
+			}
+
+			if test.Sequential {
+				clock.now = clock.now.Add(delay)
+			}
+		}
+	}
+}
+
+func TestResettingBackoffReset(t *testing.T) {
+	t.Parallel()
+
+	conf := ResettingBackoffConfig{
+		Delay:       10 * time.Millisecond,
+		MaxDelay:    time.Second,
+		ResetWindow: time.Minute,
+	}
+
+	tests := []struct {
+		Name string
+		// Successes recorded after failures, each one after the given duration
+		Successes     []time.Duration
+		ExpectedDelay time.Duration
+	}{
+		{
+			Name:          "success streak",
+			Successes:     []time.Duration{0, 30 * time.Second, 30 * time.Second},
+			ExpectedDelay: 10 * time.Millisecond,
+		},
+		{
+			Name:          "short success streak",
+			Successes:     []time.Duration{0, 30 * time.Second},
+			ExpectedDelay: 80 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		b, clock := newTestBackoff(conf)
+
+		// Escalate backoff up to 40ms
+		for range 3 {
+			clock.now = clock.now.Add(b.Delay(nil))
+		}
+
+		for _, elapsed := range test.Successes {
+			clock.now = clock.now.Add(elapsed)
+			b.RecordSuccess()
+		}
+
+		delay := b.Delay(nil)
+		if delay != test.ExpectedDelay {
+			t.Errorf("%s: expected delay %s; got %s", test.Name, test.ExpectedDelay, delay)
+		}
+	}
+
+	// Failure interrupts success streak
+	b, clock := newTestBackoff(conf)
+
+	clock.now = clock.now.Add(b.Delay(nil))
+	b.RecordSuccess()
+
+	clock.now = clock.now.Add(50 * time.Second)
+	clock.now = clock.now.Add(b.Delay(nil))
+	b.RecordSuccess()
+
+	clock.now = clock.now.Add(50 * time.Second)
+	b.RecordSuccess()
+
+	delay := b.Delay(nil)
+	if delay != 40*time.Millisecond {
+		t.Errorf("interrupted streak: expected delay %s; got %s", 40*time.Millisecond, delay)
+	}
+}
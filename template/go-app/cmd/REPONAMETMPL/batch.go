@@ -0,0 +1,203 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/req"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+)
+
+const (
+	// BatchModeAtomic commits all items or none of them.
+	BatchModeAtomic = "atomic"
+	// BatchModePartial commits valid items and reports invalid ones.
+	BatchModePartial = "partial"
+)
+
+var (
+	ErrInvalidBatchMode = errors.New("invalid batch mode")
+	ErrInvalidBatchItem = errors.New("invalid batch item")
+	ErrBatchTooLarge    = errors.New("batch too large")
+)
+
+// BatchTask is a task to insert.
+type BatchTask struct {
+	CreatedAt time.Time
+}
+
+// BatchInsertRequest is a batch of tasks to insert. Mode defaults to [BatchModeAtomic].
+type BatchInsertRequest struct {
+	Mode  string
+	Items []BatchTask
+}
+
+// BatchItemResult is the outcome of inserting a batch item.
+type BatchItemResult struct {
+	Index int
+	ID    int    `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+// BatchInsertResponse reports the outcome of each batch item, in request order.
+type BatchInsertResponse struct {
+	Committed int
+	Results   []BatchItemResult
+}
+
+func validateBatchTask(task BatchTask) error {
+	if task.CreatedAt.IsZero() {
+		return fmt.Errorf("missing creation date: %w", ErrInvalidBatchItem)
+	}
+
+	if task.CreatedAt.After(time.Now()) {
+		return fmt.Errorf("creation date in the future: %w", ErrInvalidBatchItem)
+	}
+
+	return nil
+}
+
+// insertBatch inserts items in a single transaction. In atomic mode, any failure rolls back the whole batch.
+// In partial mode, each item is inserted within its own savepoint, so that a failing item does not abort
+// the others.
+func insertBatch(ctx context.Context, client TxBeginner, mode string, items []BatchTask) (BatchInsertResponse, error) {
+	res := BatchInsertResponse{
+		Results: make([]BatchItemResult, len(items)),
+	}
+
+	err := pgx.BeginFunc(ctx, client, func(tx pgx.Tx) error {
+		for i, item := range items {
+			res.Results[i] = BatchItemResult{Index: i}
+
+			err := validateBatchTask(item)
+			if err == nil {
+				err = pgx.BeginFunc(ctx, tx, func(sp pgx.Tx) error {
+					return sp.QueryRow(
+						ctx,
+						`INSERT INTO tasks (created_at) VALUES ($1) RETURNING id`,
+						item.CreatedAt,
+					).Scan(&res.Results[i].ID)
+				})
+			}
+
+			if err != nil {
+				if mode == BatchModeAtomic {
+					return fmt.Errorf("error inserting batch item %d: %w", i, err)
+				}
+
+				if !errors.Is(err, ErrInvalidBatchItem) {
+					log.ErrLog(packageName, "error inserting batch item", err)
+				}
+
+				res.Results[i].Error = batchItemErrorMessage(err)
+
+				continue
+			}
+
+			res.Committed++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return BatchInsertResponse{}, err
+	}
+
+	return res, nil
+}
+
+// batchItemErrorMessage returns the message to report for err, without leaking internal error details.
+func batchItemErrorMessage(err error) string {
+	if errors.Is(err, ErrInvalidBatchItem) {
+		return err.Error()
+	}
+
+	return http.StatusText(http.StatusInternalServerError)
+}
+
+// NewExampleBatchInsertHandler inserts a batch of at most maxItems tasks, either all-or-nothing or partially,
+// as selected by request.
+func NewExampleBatchInsertHandler(client TxBeginner, maxItems int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batch, status, err := req.JSONFromBody[BatchInsertRequest](w, r)
+		if err != nil {
+			http.Error(w, http.StatusText(status), status)
+
+			return
+		}
+
+		if batch.Mode == "" {
+			batch.Mode = BatchModeAtomic
+		}
+
+		if batch.Mode != BatchModeAtomic && batch.Mode != BatchModePartial {
+			http.Error(
+				w,
+				fmt.Sprintf("%q: %s", batch.Mode, ErrInvalidBatchMode.Error()),
+				http.StatusBadRequest,
+			)
+
+			return
+		}
+
+		if len(batch.Items) > maxItems {
+			http.Error(
+				w,
+				ErrBatchTooLarge.Error(),
+				http.StatusRequestEntityTooLarge,
+			)
+
+			return
+		}
+
+		// Reject invalid atomic batches upfront, reporting all invalid items at once
+		if batch.Mode == BatchModeAtomic {
+			invalid := BatchInsertResponse{}
+
+			for i, item := range batch.Items {
+				err := validateBatchTask(item)
+				if err != nil {
+					invalid.Results = append(invalid.Results, BatchItemResult{
+						Index: i,
+						Error: err.Error(),
+					})
+				}
+			}
+
+			if len(invalid.Results) > 0 {
+				// Status must be written after content type, resp.JSON would set it too late
+				w.Header().Set(headkey.ContentType, headval.MIMEApplicationJSONCharsetUTF8)
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				resp.JSON(w, invalid)
+
+				return
+			}
+		}
+
+		res, err := insertBatch(r.Context(), client, batch.Mode, batch.Items)
+		if err != nil {
+			log.ErrLog(packageName, "error database batch insert", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		resp.JSON(w, res)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+)
+
+const insertTaskSQL = `INSERT INTO tasks (created_at) VALUES ($1) RETURNING id`
+
+var errFakeInsert = errors.New("insert failed")
+
+// newBatchDB returns a database assigning increasing IDs to inserted tasks, failing inserts of tasks created
+// at failAt.
+func newBatchDB(failAt time.Time) *fakeDB {
+	var id atomic.Int32
+
+	return &fakeDB{
+		row: func(_ string, args []any) ([]any, error) {
+			if args[0].(time.Time).Equal(failAt) {
+				return nil, errFakeInsert
+			}
+
+			return []any{int(id.Add(1))}, nil
+		},
+	}
+}
+
+func TestExampleBatchInsertHandler(t *testing.T) {
+	t.Parallel()
+
+	valid := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	failing := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	future := time.Now().Add(time.Hour)
+
+	mixed := []BatchTask{{CreatedAt: valid}, {}, {CreatedAt: future}, {CreatedAt: valid}}
+
+	tests := []struct {
+		Name               string
+		Request            BatchInsertRequest
+		ExpectedStatus     int
+		ExpectedResponse   BatchInsertResponse
+		ExpectedStatements []string
+	}{
+		{
+			Name:           "partial mixed validity",
+			Request:        BatchInsertRequest{Mode: BatchModePartial, Items: mixed},
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: BatchInsertResponse{
+				Committed: 2,
+				Results: []BatchItemResult{
+					{Index: 0, ID: 1},
+					{Index: 1, Error: "missing creation date: " + ErrInvalidBatchItem.Error()},
+					{Index: 2, Error: "creation date in the future: " + ErrInvalidBatchItem.Error()},
+					{Index: 3, ID: 2},
+				},
+			},
+			ExpectedStatements: []string{
+				"BEGIN",
+				"SAVEPOINT", insertTaskSQL, "RELEASE SAVEPOINT",
+				"SAVEPOINT", insertTaskSQL, "RELEASE SAVEPOINT",
+				"COMMIT",
+			},
+		},
+		{
+			Name: "partial database failure",
+			Request: BatchInsertRequest{
+				Mode:  BatchModePartial,
+				Items: []BatchTask{{CreatedAt: failing}, {CreatedAt: valid}},
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: BatchInsertResponse{
+				Committed: 1,
+				Results: []BatchItemResult{
+					{Index: 0, Error: http.StatusText(http.StatusInternalServerError)},
+					{Index: 1, ID: 1},
+				},
+			},
+			ExpectedStatements: []string{
+				"BEGIN",
+				"SAVEPOINT", insertTaskSQL, "ROLLBACK TO SAVEPOINT",
+				"SAVEPOINT", insertTaskSQL, "RELEASE SAVEPOINT",
+				"COMMIT",
+			},
+		},
+		{
+			Name:           "atomic mixed validity",
+			Request:        BatchInsertRequest{Mode: BatchModeAtomic, Items: mixed},
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedResponse: BatchInsertResponse{
+				Results: []BatchItemResult{
+					{Index: 1, Error: "missing creation date: " + ErrInvalidBatchItem.Error()},
+					{Index: 2, Error: "creation date in the future: " + ErrInvalidBatchItem.Error()},
+				},
+			},
+			ExpectedStatements: []string{},
+		},
+		{
+			Name: "atomic valid",
+			Request: BatchInsertRequest{
+				Items: []BatchTask{{CreatedAt: valid}, {CreatedAt: valid}},
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: BatchInsertResponse{
+				Committed: 2,
+				Results:   []BatchItemResult{{Index: 0, ID: 1}, {Index: 1, ID: 2}},
+			},
+			ExpectedStatements: []string{
+				"BEGIN",
+				"SAVEPOINT", insertTaskSQL, "RELEASE SAVEPOINT",
+				"SAVEPOINT", insertTaskSQL, "RELEASE SAVEPOINT",
+				"COMMIT",
+			},
+		},
+		{
+			Name: "atomic database failure",
+			Request: BatchInsertRequest{
+				Mode:  BatchModeAtomic,
+				Items: []BatchTask{{CreatedAt: valid}, {CreatedAt: failing}},
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedStatements: []string{
+				"BEGIN",
+				"SAVEPOINT", insertTaskSQL, "RELEASE SAVEPOINT",
+				"SAVEPOINT", insertTaskSQL, "ROLLBACK TO SAVEPOINT",
+				"ROLLBACK",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		db := newBatchDB(failing)
+		handler := NewExampleBatchInsertHandler(db, 10)
+
+		body, err := json.Marshal(test.Request)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %s", test.Name, err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/database/batch", strings.NewReader(string(body)))
+		req.Header.Set(headkey.ContentType, headval.MIMEApplicationJSON)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		if !slices.Equal(db.SQL(), test.ExpectedStatements) {
+			t.Errorf("%s: expected statements %q; got %q", test.Name, test.ExpectedStatements, db.SQL())
+		}
+
+		if test.ExpectedStatus == http.StatusInternalServerError {
+			continue
+		}
+
+		var res BatchInsertResponse
+
+		err = json.Unmarshal(rr.Body.Bytes(), &res)
+		if err != nil {
+			t.Fatalf("%s: Unmarshal: %s", test.Name, err)
+		}
+
+		if res.Committed != test.ExpectedResponse.Committed ||
+			!slices.Equal(res.Results, test.ExpectedResponse.Results) {
+			t.Errorf("%s: expected response %+v; got %+v", test.Name, test.ExpectedResponse, res)
+		}
+	}
+}
+
+func TestExampleBatchInsertHandlerRejected(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name           string
+		Request        BatchInsertRequest
+		ExpectedStatus int
+	}{
+		{
+			Name:           "invalid mode",
+			Request:        BatchInsertRequest{Mode: "eventual"},
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "too large",
+			Request:        BatchInsertRequest{Items: make([]BatchTask, 3)},
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		db := &fakeDB{}
+		handler := NewExampleBatchInsertHandler(db, 2)
+
+		body, err := json.Marshal(test.Request)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %s", test.Name, err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/database/batch", strings.NewReader(string(body)))
+		req.Header.Set(headkey.ContentType, headval.MIMEApplicationJSON)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		if len(db.SQL()) != 0 {
+			t.Errorf("%s: expected no statement; got %q", test.Name, db.SQL())
+		}
+	}
+}
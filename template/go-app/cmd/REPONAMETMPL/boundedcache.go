@@ -0,0 +1,236 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// BoundedCacheMaxKeysDefault is the default maximum number of distinct keys across namespaces.
+	BoundedCacheMaxKeysDefault = 100000
+	// BoundedCacheMaxMetricNamespacesDefault is the default maximum number of namespaces recorded with
+	// their own metric attribute.
+	BoundedCacheMaxMetricNamespacesDefault = 100
+	// CacheNamespaceOverflow is the metric attribute value of namespaces beyond
+	// [BoundedCacheConfig.MaxMetricNamespaces].
+	CacheNamespaceOverflow = "_overflow"
+)
+
+// BoundedCacheConfig defines the configuration for bounded local cache.
+type BoundedCacheConfig struct {
+	// Maximum number of distinct keys per namespace, least recently used keys of namespace are evicted beyond
+	// it. Zero disables the limit
+	MaxKeysPerNamespace int
+	// Maximum number of distinct keys across namespaces, least recently used keys are evicted beyond it, so
+	// that high-cardinality namespaces can't exhaust memory either. Zero uses [BoundedCacheMaxKeysDefault]
+	MaxKeys int
+	// Maximum number of namespaces recorded with their own metric attribute, others being recorded as
+	// [CacheNamespaceOverflow]. Zero uses [BoundedCacheMaxMetricNamespacesDefault]
+	MaxMetricNamespaces int
+	// Namespace returns the namespace of key, nil uses [CacheKeyNamespace]
+	Namespace func(key string) string
+}
+
+// CacheKeyNamespace returns the part of key before its first colon, or an empty namespace if there is none.
+func CacheKeyNamespace(key string) string {
+	namespace, _, found := strings.Cut(key, ":")
+	if !found {
+		return ""
+	}
+
+	return namespace
+}
+
+// boundedCacheEntry is an entry of both its namespace LRU list and cache-wide one.
+type boundedCacheEntry[V any] struct {
+	key        string
+	value      V
+	namespace  *boundedCacheNamespace
+	nsElem     *list.Element
+	globalElem *list.Element
+}
+
+// boundedCacheNamespace is the LRU list of a namespace, most recently used keys first.
+type boundedCacheNamespace struct {
+	name     string
+	lru      *list.List
+	elements map[string]*list.Element
+	attrs    metric.MeasurementOption
+}
+
+// BoundedCache is a local cache bounding the number of distinct keys, per namespace and overall, with LRU
+// eviction, so that unbounded keys (e.g. derived from user queries) can't exhaust memory. Namespaces are
+// dropped once their last key is evicted. It satisfies failsafe cache interface, and records key cardinality
+// per namespace, with bounded metric attribute cardinality. It is safe for concurrent use.
+type BoundedCache[V any] struct {
+	conf       BoundedCacheConfig
+	mu         sync.Mutex
+	namespaces map[string]*boundedCacheNamespace
+	// Cache-wide LRU list, most recently used keys first
+	lru *list.List
+	// Measurement options of namespaces recorded with their own attribute, never shrinking as recorded
+	// streams outlive namespaces
+	metricAttrs map[string]metric.MeasurementOption
+	keys        metric.Int64UpDownCounter
+	evictions   metric.Int64Counter
+}
+
+// NewBoundedCache returns a bounded local cache.
+func NewBoundedCache[V any](conf BoundedCacheConfig) (*BoundedCache[V], error) {
+	if conf.Namespace == nil {
+		conf.Namespace = CacheKeyNamespace
+	}
+
+	if conf.MaxKeys <= 0 {
+		conf.MaxKeys = BoundedCacheMaxKeysDefault
+	}
+
+	if conf.MaxMetricNamespaces <= 0 {
+		conf.MaxMetricNamespaces = BoundedCacheMaxMetricNamespacesDefault
+	}
+
+	meter := otel.GetMeterProvider().Meter(packageName)
+
+	keys, err := meter.Int64UpDownCounter(
+		"cache.local.keys",
+		metric.WithDescription("Number of distinct keys in local cache"),
+		metric.WithUnit("{key}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cache keys metric: %w", err)
+	}
+
+	evictions, err := meter.Int64Counter(
+		"cache.local.evictions.total",
+		metric.WithDescription("Total number of keys evicted from local cache due to cardinality limit"),
+		metric.WithUnit("{key}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cache evictions metric: %w", err)
+	}
+
+	return &BoundedCache[V]{
+		conf:        conf,
+		namespaces:  make(map[string]*boundedCacheNamespace),
+		lru:         list.New(),
+		metricAttrs: make(map[string]metric.MeasurementOption),
+		keys:        keys,
+		evictions:   evictions,
+	}, nil
+}
+
+// Get returns the value of key, marking it as recently used.
+func (c *BoundedCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ns, ok := c.namespaces[c.conf.Namespace(key)]
+	if !ok {
+		return *(new(V)), false
+	}
+
+	elem, ok := ns.elements[key]
+	if !ok {
+		return *(new(V)), false
+	}
+
+	entry := elem.Value.(*boundedCacheEntry[V])
+	ns.lru.MoveToFront(entry.nsElem)
+	c.lru.MoveToFront(entry.globalElem)
+
+	return entry.value, true
+}
+
+// Set sets the value of key, evicting least recently used keys if limits are exceeded.
+func (c *BoundedCache[V]) Set(key string, value V) {
+	ctx := context.Background()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := c.conf.Namespace(key)
+
+	ns, ok := c.namespaces[name]
+	if !ok {
+		ns = &boundedCacheNamespace{
+			name:     name,
+			lru:      list.New(),
+			elements: make(map[string]*list.Element),
+			attrs:    c.metricAttributes(name),
+		}
+		c.namespaces[name] = ns
+	}
+
+	elem, ok := ns.elements[key]
+	if ok {
+		entry := elem.Value.(*boundedCacheEntry[V])
+		entry.value = value
+		ns.lru.MoveToFront(entry.nsElem)
+		c.lru.MoveToFront(entry.globalElem)
+
+		return
+	}
+
+	entry := &boundedCacheEntry[V]{key: key, value: value, namespace: ns}
+	entry.nsElem = ns.lru.PushFront(entry)
+	entry.globalElem = c.lru.PushFront(entry)
+	ns.elements[key] = entry.nsElem
+	c.keys.Add(ctx, 1, ns.attrs)
+
+	for c.conf.MaxKeysPerNamespace > 0 && ns.lru.Len() > c.conf.MaxKeysPerNamespace {
+		c.evict(ctx, ns.lru.Back().Value.(*boundedCacheEntry[V]))
+	}
+
+	for c.lru.Len() > c.conf.MaxKeys {
+		c.evict(ctx, c.lru.Back().Value.(*boundedCacheEntry[V]))
+	}
+}
+
+// evict removes entry, dropping its namespace if it becomes empty. c.mu must be held.
+func (c *BoundedCache[V]) evict(ctx context.Context, entry *boundedCacheEntry[V]) {
+	ns := entry.namespace
+
+	ns.lru.Remove(entry.nsElem)
+	c.lru.Remove(entry.globalElem)
+	delete(ns.elements, entry.key)
+
+	if ns.lru.Len() == 0 {
+		delete(c.namespaces, ns.name)
+	}
+
+	c.keys.Add(ctx, -1, ns.attrs)
+	c.evictions.Add(ctx, 1, ns.attrs)
+}
+
+// metricAttributes returns the measurement option of namespace name, that is the overflow one once maximum
+// number of metric namespaces is reached. c.mu must be held.
+func (c *BoundedCache[V]) metricAttributes(name string) metric.MeasurementOption {
+	attrs, ok := c.metricAttrs[name]
+	if ok {
+		return attrs
+	}
+
+	if len(c.metricAttrs) >= c.conf.MaxMetricNamespaces {
+		name = CacheNamespaceOverflow
+	}
+
+	attrs = metric.WithAttributes(attribute.String("cache.namespace", name))
+
+	if name != CacheNamespaceOverflow {
+		c.metricAttrs[name] = attrs
+	}
+
+	return attrs
+}
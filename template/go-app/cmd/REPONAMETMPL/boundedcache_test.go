@@ -0,0 +1,137 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func TestBoundedCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name string
+		Conf BoundedCacheConfig
+		// Keys to set, in order, after which "a:1" is read back
+		Set             []string
+		ExpectedPresent []string
+		ExpectedEvicted []string
+	}{
+		{
+			Name:            "within limits",
+			Conf:            BoundedCacheConfig{MaxKeysPerNamespace: 3, MaxKeys: 10},
+			Set:             []string{"a:1", "a:2", "b:1"},
+			ExpectedPresent: []string{"a:1", "a:2", "b:1"},
+		},
+		{
+			Name:            "namespace limit",
+			Conf:            BoundedCacheConfig{MaxKeysPerNamespace: 2, MaxKeys: 10},
+			Set:             []string{"a:1", "a:2", "b:1", "a:3"},
+			ExpectedPresent: []string{"a:2", "a:3", "b:1"},
+			ExpectedEvicted: []string{"a:1"},
+		},
+		{
+			Name:            "total limit across namespaces",
+			Conf:            BoundedCacheConfig{MaxKeysPerNamespace: 2, MaxKeys: 3},
+			Set:             []string{"a:1", "b:1", "c:1", "d:1"},
+			ExpectedPresent: []string{"b:1", "c:1", "d:1"},
+			ExpectedEvicted: []string{"a:1"},
+		},
+	}
+
+	for _, test := range tests {
+		c, err := NewBoundedCache[int](test.Conf)
+		if err != nil {
+			t.Fatalf("%s: NewBoundedCache: %s", test.Name, err)
+		}
+
+		for i, key := range test.Set {
+			c.Set(key, i)
+		}
+
+		for _, key := range test.ExpectedPresent {
+			_, ok := c.Get(key)
+			if !ok {
+				t.Errorf("%s: expected %q to be present", test.Name, key)
+			}
+		}
+
+		for _, key := range test.ExpectedEvicted {
+			_, ok := c.Get(key)
+			if ok {
+				t.Errorf("%s: expected %q to be evicted", test.Name, key)
+			}
+		}
+	}
+}
+
+func TestBoundedCacheRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewBoundedCache[int](BoundedCacheConfig{MaxKeys: 2})
+	if err != nil {
+		t.Fatalf("NewBoundedCache: %s", err)
+	}
+
+	c.Set("a:1", 1)
+	c.Set("b:1", 2)
+
+	// Reading refreshes key, other one becomes least recently used
+	_, ok := c.Get("a:1")
+	if !ok {
+		t.Fatalf("expected %q to be present", "a:1")
+	}
+
+	c.Set("c:1", 3)
+
+	_, ok = c.Get("b:1")
+	if ok {
+		t.Errorf("expected %q to be evicted", "b:1")
+	}
+
+	value, ok := c.Get("a:1")
+	if !ok || value != 1 {
+		t.Errorf("expected %q to be %d; got %d, present %t", "a:1", 1, value, ok)
+	}
+}
+
+func TestBoundedCacheNamespaces(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewBoundedCache[int](BoundedCacheConfig{MaxKeys: 5, MaxMetricNamespaces: 2})
+	if err != nil {
+		t.Fatalf("NewBoundedCache: %s", err)
+	}
+
+	// High-cardinality namespaces
+	for i := range 20 {
+		c.Set(strconv.Itoa(i)+":key", i)
+	}
+
+	// Namespaces are dropped along with their last key
+	if len(c.namespaces) != 5 {
+		t.Errorf("expected %d namespaces; got %d", 5, len(c.namespaces))
+	}
+
+	if len(c.metricAttrs) != 2 {
+		t.Errorf("expected %d metric namespaces; got %d", 2, len(c.metricAttrs))
+	}
+
+	overflow := metric.NewAddConfig([]metric.AddOption{
+		metric.WithAttributes(attribute.String("cache.namespace", CacheNamespaceOverflow)),
+	}).Attributes()
+
+	for name, ns := range c.namespaces {
+		attrs := metric.NewAddConfig([]metric.AddOption{ns.attrs}).Attributes()
+		if !attrs.Equals(&overflow) {
+			t.Errorf("%q: expected overflow metric attributes; got %v", name, attrs.ToSlice())
+		}
+	}
+}
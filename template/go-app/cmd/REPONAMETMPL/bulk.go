@@ -0,0 +1,208 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/req"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// ErrBulkRejected is returned by bulk attempts whose items were rejected due to backpressure. Retry
+// policies used with [BulkIndex] should handle it, and only it.
+var ErrBulkRejected = errors.New("bulk items rejected by backpressure")
+
+// BulkDocument is a document to index.
+type BulkDocument struct {
+	ID     string
+	Source json.RawMessage
+}
+
+// BulkItemFailure is a document that could not be indexed.
+type BulkItemFailure struct {
+	ID     string
+	Status int
+	Reason string
+}
+
+// BulkIndexResult reports the outcome of a bulk indexing.
+type BulkIndexResult struct {
+	Indexed int
+	Failed  []BulkItemFailure
+}
+
+// BulkIndex indexes docs in index through exec. Items rejected with 429 (e.g. bulk queue full) are retried
+// according to exec policies, without resending items that were already indexed. Other item failures are
+// reported without being retried, as are rejected items once retries are exceeded.
+func BulkIndex(
+	ctx context.Context,
+	client *opensearchapi.Client,
+	exec failsafe.Executor[any],
+	index string,
+	docs []BulkDocument,
+) (BulkIndexResult, error) {
+	var res BulkIndexResult
+
+	pending := docs
+	// Last rejection of each pending item, if known
+	var rejections []BulkItemFailure
+
+	err := exec.Run(func() error {
+		body, err := bulkBody(pending)
+		if err != nil {
+			return err
+		}
+
+		bresp, err := client.Bulk(ctx, opensearchapi.BulkReq{
+			Index: index,
+			Body:  bytes.NewReader(body),
+		})
+		if err != nil {
+			// Whole request rejected, retry all pending items
+			if bresp != nil && bresp.Inspect().Response != nil &&
+				bresp.Inspect().Response.StatusCode == http.StatusTooManyRequests {
+				return fmt.Errorf("%w: %w", ErrBulkRejected, err)
+			}
+
+			return fmt.Errorf("error bulk indexing: %w", err)
+		}
+
+		var (
+			retry          []BulkDocument
+			retryRejection []BulkItemFailure
+		)
+
+		// Items are returned in request order
+		for i, item := range bresp.Items {
+			if i >= len(pending) {
+				break
+			}
+
+			for _, result := range item {
+				switch {
+				case result.Status == http.StatusTooManyRequests:
+					retry = append(retry, pending[i])
+					retryRejection = append(retryRejection, bulkItemFailure(pending[i].ID, result))
+				case result.Error != nil || result.Status >= http.StatusBadRequest:
+					res.Failed = append(res.Failed, bulkItemFailure(pending[i].ID, result))
+				default:
+					res.Indexed++
+				}
+			}
+		}
+
+		pending = retry
+		rejections = retryRejection
+		if len(pending) > 0 {
+			return fmt.Errorf("%d items: %w", len(pending), ErrBulkRejected)
+		}
+
+		return nil
+	})
+
+	// Items still rejected once retries are exceeded are failures
+	for i, doc := range pending {
+		if i < len(rejections) {
+			res.Failed = append(res.Failed, rejections[i])
+
+			continue
+		}
+
+		res.Failed = append(res.Failed, BulkItemFailure{
+			ID:     doc.ID,
+			Status: http.StatusTooManyRequests,
+			Reason: ErrBulkRejected.Error(),
+		})
+	}
+
+	if err != nil && !errors.Is(err, ErrBulkRejected) {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func bulkItemFailure(id string, item opensearchapi.BulkRespItem) BulkItemFailure {
+	failure := BulkItemFailure{
+		ID:     id,
+		Status: item.Status,
+	}
+
+	if item.Error != nil {
+		failure.Reason = item.Error.Reason
+	}
+
+	return failure
+}
+
+// bulkBody returns the newline-delimited JSON bulk request body indexing docs.
+func bulkBody(docs []BulkDocument) ([]byte, error) {
+	var buf bytes.Buffer
+
+	type indexAction struct {
+		Index struct {
+			ID string `json:"_id,omitempty"`
+		} `json:"index"`
+	}
+
+	enc := json.NewEncoder(&buf)
+
+	for _, doc := range docs {
+		var action indexAction
+		action.Index.ID = doc.ID
+
+		err := enc.Encode(action)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding bulk action: %w", err)
+		}
+
+		// Compact source as bulk API requires a document per line
+		err = json.Compact(&buf, doc.Source)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding bulk document %s: %w", doc.ID, err)
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// NewExampleSearchBulkHandler indexes documents of request body in bulk, retrying items rejected by
+// backpressure through exec.
+func NewExampleSearchBulkHandler(client *opensearchapi.Client, exec failsafe.Executor[any]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		docs, status, err := req.JSONFromBody[[]BulkDocument](w, r)
+		if err != nil {
+			http.Error(w, http.StatusText(status), status)
+
+			return
+		}
+
+		res, err := BulkIndex(r.Context(), client, exec, "example", docs)
+		if err != nil {
+			log.ErrLog(packageName, "error search bulk index", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		resp.JSON(w, res)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/failsafe-go/failsafe-go/retrypolicy"
+	"github.com/opensearch-project/opensearch-go/v4"
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// bulkRoundTripper answers bulk requests, each item getting the next of its statuses, and the last one
+// once exhausted. It records IDs of items sent by each request.
+type bulkRoundTripper struct {
+	mu       sync.Mutex
+	statuses map[string][]int
+	sent     [][]string
+}
+
+func (rt *bulkRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	var (
+		ids   []string
+		items []map[string]any
+	)
+
+	scanner := bufio.NewScanner(r.Body)
+
+	// Action lines alternate with document lines
+	for action := true; scanner.Scan(); action = !action {
+		if !action {
+			continue
+		}
+
+		var line struct {
+			Index struct {
+				ID string `json:"_id"`
+			} `json:"index"`
+		}
+
+		err := json.Unmarshal(scanner.Bytes(), &line)
+		if err != nil {
+			return nil, err
+		}
+
+		id := line.Index.ID
+		ids = append(ids, id)
+
+		status := rt.statuses[id][0]
+		if len(rt.statuses[id]) > 1 {
+			rt.statuses[id] = rt.statuses[id][1:]
+		}
+
+		item := map[string]any{"_id": id, "status": status}
+		if status >= http.StatusBadRequest {
+			item["error"] = map[string]any{"type": "exception", "reason": http.StatusText(status)}
+		}
+
+		items = append(items, map[string]any{"index": item})
+	}
+
+	rt.sent = append(rt.sent, ids)
+
+	body, err := json.Marshal(map[string]any{"took": 1, "errors": true, "items": items})
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+func TestBulkIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name            string
+		Statuses        map[string][]int
+		ExpectedSent    [][]string
+		ExpectedIndexed int
+		ExpectedFailed  []BulkItemFailure
+	}{
+		{
+			Name: "partial rejections",
+			Statuses: map[string][]int{
+				"a": {http.StatusCreated},
+				"b": {http.StatusTooManyRequests, http.StatusCreated},
+				"c": {http.StatusBadRequest},
+				"d": {http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusCreated},
+			},
+			ExpectedSent:    [][]string{{"a", "b", "c", "d"}, {"b", "d"}, {"d"}},
+			ExpectedIndexed: 3,
+			ExpectedFailed: []BulkItemFailure{
+				{ID: "c", Status: http.StatusBadRequest, Reason: http.StatusText(http.StatusBadRequest)},
+			},
+		},
+		{
+			Name: "retries exceeded",
+			Statuses: map[string][]int{
+				"a": {http.StatusCreated},
+				"b": {http.StatusCreated},
+				"c": {http.StatusCreated},
+				"d": {http.StatusTooManyRequests},
+			},
+			ExpectedSent:    [][]string{{"a", "b", "c", "d"}, {"d"}, {"d"}, {"d"}},
+			ExpectedIndexed: 3,
+			ExpectedFailed: []BulkItemFailure{
+				{ID: "d", Status: http.StatusTooManyRequests, Reason: http.StatusText(http.StatusTooManyRequests)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		rt := &bulkRoundTripper{statuses: test.Statuses}
+
+		client, err := opensearchapi.NewClient(opensearchapi.Config{
+			Client: opensearch.Config{
+				Addresses: []string{"http://search.invalid"},
+				Transport: rt,
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: NewClient: %s", test.Name, err)
+		}
+
+		exec := failsafe.With[any](
+			retrypolicy.NewBuilder[any]().HandleErrors(ErrBulkRejected).WithMaxRetries(3).Build(),
+		)
+
+		docs := make([]BulkDocument, 0, 4)
+		for _, id := range []string{"a", "b", "c", "d"} {
+			docs = append(docs, BulkDocument{ID: id, Source: json.RawMessage(`{"name": "` + id + `"}`)})
+		}
+
+		res, err := BulkIndex(context.Background(), client, exec, "example", docs)
+		if err != nil {
+			t.Fatalf("%s: BulkIndex: %s", test.Name, err)
+		}
+
+		// Only rejected items are resent
+		if !slices.EqualFunc(rt.sent, test.ExpectedSent, slices.Equal) {
+			t.Errorf("%s: expected sent items %q; got %q", test.Name, test.ExpectedSent, rt.sent)
+		}
+
+		if res.Indexed != test.ExpectedIndexed {
+			t.Errorf("%s: expected %d indexed; got %d", test.Name, test.ExpectedIndexed, res.Indexed)
+		}
+
+		if !slices.Equal(res.Failed, test.ExpectedFailed) {
+			t.Errorf("%s: expected failures %+v; got %+v", test.Name, test.ExpectedFailed, res.Failed)
+		}
+	}
+}
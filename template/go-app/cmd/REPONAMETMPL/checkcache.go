@@ -0,0 +1,41 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/monitoring"
+)
+
+// NewCachedChecker returns a checker reusing results of checker for ttl, so that frequent probes don't issue
+// checks (e.g. dependency pings) on each call. Concurrent calls during a check wait for its results instead
+// of issuing their own. A zero ttl disables caching.
+func NewCachedChecker(
+	checker func() monitoring.CheckResults,
+	ttl time.Duration,
+) func() monitoring.CheckResults {
+	var (
+		mu        sync.Mutex
+		results   monitoring.CheckResults
+		checkedAt time.Time
+	)
+
+	return func() monitoring.CheckResults {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if results == nil || time.Since(checkedAt) >= ttl {
+			results = checker()
+			checkedAt = time.Now()
+		}
+
+		// Callers may modify results
+		return maps.Clone(results)
+	}
+}
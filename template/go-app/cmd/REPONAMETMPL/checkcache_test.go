@@ -0,0 +1,96 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/monitoring"
+)
+
+// newCountingChecker returns a checker taking delay, and the number of times it ran.
+func newCountingChecker(delay time.Duration) (func() monitoring.CheckResults, *atomic.Int32) {
+	var checks atomic.Int32
+
+	return func() monitoring.CheckResults {
+		checks.Add(1)
+		time.Sleep(delay)
+
+		return monitoring.CheckResults{
+			"database": {Status: monitoring.StatusOK, Message: monitoring.StatusOK.String()},
+		}
+	}, &checks
+}
+
+func TestCachedChecker(t *testing.T) {
+	t.Parallel()
+
+	checker, checks := newCountingChecker(0)
+	cached := NewCachedChecker(checker, 200*time.Millisecond)
+
+	// Probes within TTL reuse results
+	for range 5 {
+		results := cached()
+		if results["database"].Status != monitoring.StatusOK {
+			t.Errorf("expected status %q; got %q", monitoring.StatusOK, results["database"].Status)
+		}
+
+		// Callers modifying results don't affect other ones
+		delete(results, "database")
+	}
+
+	if checks.Load() != 1 {
+		t.Errorf("expected %d check within TTL; got %d", 1, checks.Load())
+	}
+
+	time.Sleep(250 * time.Millisecond)
+
+	cached()
+
+	if checks.Load() != 2 {
+		t.Errorf("expected %d checks after TTL; got %d", 2, checks.Load())
+	}
+}
+
+func TestCachedCheckerConcurrent(t *testing.T) {
+	t.Parallel()
+
+	checker, checks := newCountingChecker(50 * time.Millisecond)
+	cached := NewCachedChecker(checker, time.Minute)
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Go(func() {
+			cached()
+		})
+	}
+
+	wg.Wait()
+
+	// Probes during a check wait for its results
+	if checks.Load() != 1 {
+		t.Errorf("expected %d check; got %d", 1, checks.Load())
+	}
+}
+
+func TestCachedCheckerDisabled(t *testing.T) {
+	t.Parallel()
+
+	checker, checks := newCountingChecker(0)
+	cached := NewCachedChecker(checker, 0)
+
+	for range 3 {
+		cached()
+	}
+
+	if checks.Load() != 3 {
+		t.Errorf("expected %d checks; got %d", 3, checks.Load())
+	}
+}
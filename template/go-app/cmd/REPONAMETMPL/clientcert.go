@@ -0,0 +1,180 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/config"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+)
+
+var (
+	ErrNoClientCert      = errors.New("no client certificate presented")
+	ErrInvalidClientCert = errors.New("invalid client certificate")
+	ErrNoPrincipal       = errors.New("no principal in context")
+	ErrNoClientCertRoots = errors.New("no client certificate roots")
+)
+
+// ClientCertRootsEnvVar is the environment variable holding the path of the PEM encoded certificate authorities
+// client certificates must chain to. Client certificate authentication is disabled when it is unset or empty,
+// and requires a server terminating TLS, which [server.Run] is not.
+var ClientCertRootsEnvVar = strings.ToUpper(config.ConfigurationEnvVarPrefix) + "_SERVER_CLIENT_CA_FILE_PATH"
+
+// ClientCertConfig defines the configuration for client certificate authentication middleware.
+type ClientCertConfig struct {
+	// Certificate authorities client certificates must chain to, typically an internal CA. It is required, as
+	// publicly trusted authorities issue certificates to anyone
+	Roots *x509.CertPool
+	// Principals allowed to access protected routes, empty allows any principal with a certificate chaining
+	// to Roots
+	AllowedPrincipals []string
+	// Principal maps a verified certificate to a principal, nil uses [ClientCertPrincipal]
+	Principal func(cert *x509.Certificate) (string, error)
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the principal held by ctx, if any.
+func PrincipalFromContext(ctx context.Context) (string, bool) {
+	principal, ok := ctx.Value(principalKey{}).(string)
+
+	return principal, ok && principal != ""
+}
+
+// LoadClientCertRoots returns a pool of the PEM encoded certificates held by file at path.
+func LoadClientCertRoots(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading client certificate roots: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoClientCertRoots)
+	}
+
+	return roots, nil
+}
+
+// ClientCertPrincipal maps cert to its subject common name.
+func ClientCertPrincipal(cert *x509.Certificate) (string, error) {
+	if cert.Subject.CommonName == "" {
+		return "", fmt.Errorf("empty subject common name: %w", ErrInvalidClientCert)
+	}
+
+	return cert.Subject.CommonName, nil
+}
+
+// VerifyClientCert verifies the client certificate of r against roots, returning the verified leaf.
+func VerifyClientCert(r *http.Request, roots *x509.CertPool) (*x509.Certificate, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, ErrNoClientCert
+	}
+
+	leaf := r.TLS.PeerCertificates[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidClientCert, err)
+	}
+
+	return leaf, nil
+}
+
+// NewClientCertMiddleware returns a middleware that authenticates requests using their TLS client certificate,
+// storing the derived principal in request context. Requests without a valid certificate are rejected with 401,
+// and requests whose principal is not allowed with 403.
+// It requires TLS to be terminated by the application itself, with client certificates requested, e.g. using
+// [crypto/tls.VerifyClientCertIfGiven], as [net/http.Request.TLS] is nil otherwise.
+func NewClientCertMiddleware(conf ClientCertConfig) (func(http.Handler) http.Handler, error) {
+	if conf.Roots == nil {
+		return nil, ErrNoClientCertRoots
+	}
+
+	if conf.Principal == nil {
+		conf.Principal = ClientCertPrincipal
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cert, err := VerifyClientCert(r, conf.Roots)
+			if err != nil {
+				http.Error(
+					w,
+					http.StatusText(http.StatusUnauthorized),
+					http.StatusUnauthorized,
+				)
+
+				return
+			}
+
+			principal, err := conf.Principal(cert)
+			if err != nil {
+				log.ErrLog(packageName, "error deriving client certificate principal", err)
+				http.Error(
+					w,
+					http.StatusText(http.StatusUnauthorized),
+					http.StatusUnauthorized,
+				)
+
+				return
+			}
+
+			if len(conf.AllowedPrincipals) > 0 && !slices.Contains(conf.AllowedPrincipals, principal) {
+				http.Error(
+					w,
+					http.StatusText(http.StatusForbidden),
+					http.StatusForbidden,
+				)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+		})
+	}, nil
+}
+
+// NewExampleWhoAmIHandler returns the principal authenticated by [NewClientCertMiddleware].
+func NewExampleWhoAmIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			log.ErrLog(packageName, "error getting principal", ErrNoPrincipal)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		type ExampleOutput struct {
+			Principal string
+		}
+
+		resp.JSON(w, ExampleOutput{Principal: principal})
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority issuing client certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %s", err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return pool
+}
+
+// issue returns a client certificate for commonName.
+func (ca *testCA) issue(t *testing.T, commonName string) *x509.Certificate {
+	t.Helper()
+
+	cert, _ := ca.issueWithKey(t, commonName)
+
+	return cert
+}
+
+// issueWithKey returns a client certificate for commonName, along with its private key.
+func (ca *testCA) issueWithKey(t *testing.T, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %s", err)
+	}
+
+	return cert, key
+}
+
+func TestClientCertMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	otherCA := newTestCA(t)
+
+	mw, err := NewClientCertMiddleware(ClientCertConfig{
+		Roots:             ca.pool(),
+		AllowedPrincipals: []string{"svc-a"},
+	})
+	if err != nil {
+		t.Fatalf("NewClientCertMiddleware: %s", err)
+	}
+
+	handler := mw(NewExampleWhoAmIHandler())
+
+	tests := []struct {
+		Name           string
+		TLS            *tls.ConnectionState
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "allowed principal",
+			TLS:            &tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, "svc-a")}},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"Principal":"svc-a"}`,
+		},
+		{
+			Name:           "no TLS",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "no certificate",
+			TLS:            &tls.ConnectionState{},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "untrusted authority",
+			TLS:            &tls.ConnectionState{PeerCertificates: []*x509.Certificate{otherCA.issue(t, "svc-a")}},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "empty principal",
+			TLS:            &tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, "")}},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "principal not allowed",
+			TLS:            &tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, "svc-b")}},
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/internal/whoami", nil)
+		req.TLS = test.TLS
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		if test.ExpectedBody != "" && strings.TrimSpace(rr.Body.String()) != test.ExpectedBody {
+			t.Errorf("%s: expected body %q; got %q", test.Name, test.ExpectedBody, rr.Body.String())
+		}
+	}
+}
+
+func TestClientCertMiddlewareServer(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+
+	mw, err := NewClientCertMiddleware(ClientCertConfig{Roots: ca.pool()})
+	if err != nil {
+		t.Fatalf("NewClientCertMiddleware: %s", err)
+	}
+
+	cert, key := ca.issueWithKey(t, "svc-a")
+	clientCert := tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+
+	plainServer := httptest.NewServer(mw(NewExampleWhoAmIHandler()))
+	t.Cleanup(plainServer.Close)
+
+	// Server terminating TLS, requesting client certificates that middleware then verifies
+	tlsServer := httptest.NewUnstartedServer(mw(NewExampleWhoAmIHandler()))
+	tlsServer.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: ca.pool()}
+	tlsServer.StartTLS()
+	t.Cleanup(tlsServer.Close)
+
+	tests := []struct {
+		Name           string
+		Server         *httptest.Server
+		ClientCert     bool
+		ExpectedStatus int
+	}{
+		{
+			Name:           "plain HTTP",
+			Server:         plainServer,
+			ClientCert:     true,
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "TLS with client certificate",
+			Server:         tlsServer,
+			ClientCert:     true,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "TLS without client certificate",
+			Server:         tlsServer,
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		// Server client is shared, copy it so that certificates don't leak across cases
+		client := *test.Server.Client()
+		client.Timeout = 5 * time.Second
+
+		if test.ClientCert {
+			transport := client.Transport.(*http.Transport).Clone()
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+
+			transport.TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+			client.Transport = transport
+		}
+
+		res, err := client.Get(test.Server.URL + "/internal/whoami")
+		if err != nil {
+			t.Fatalf("%s: Get: %s", test.Name, err)
+		}
+
+		_ = res.Body.Close()
+
+		if res.StatusCode != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, res.StatusCode)
+		}
+	}
+}
+
+func TestClientCertMiddlewareNoRoots(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClientCertMiddleware(ClientCertConfig{})
+	if !errors.Is(err, ErrNoClientCertRoots) {
+		t.Errorf("expected %s; got %v", ErrNoClientCertRoots, err)
+	}
+}
+
+func TestLoadClientCertRoots(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	validPath := filepath.Join(dir, "ca.pem")
+
+	err := os.WriteFile(validPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	invalidPath := filepath.Join(dir, "invalid.pem")
+
+	err = os.WriteFile(invalidPath, []byte("not a certificate"), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	roots, err := LoadClientCertRoots(validPath)
+	if err != nil {
+		t.Fatalf("LoadClientCertRoots: %s", err)
+	}
+
+	_, err = ca.issue(t, "svc-a").Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Errorf("expected certificate issued by loaded root to verify; got %s", err)
+	}
+
+	_, err = LoadClientCertRoots(invalidPath)
+	if !errors.Is(err, ErrNoClientCertRoots) {
+		t.Errorf("expected %s; got %v", ErrNoClientCertRoots, err)
+	}
+
+	_, err = LoadClientCertRoots(filepath.Join(dir, "missing.pem"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %s; got %v", os.ErrNotExist, err)
+	}
+}
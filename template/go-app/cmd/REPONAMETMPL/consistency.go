@@ -0,0 +1,188 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kemadev/go-framework/pkg/client/database"
+	"github.com/kemadev/go-framework/pkg/config"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/kemadev/go-framework/pkg/monitoring"
+	"github.com/valkey-io/valkey-go"
+)
+
+// SessionHeader is the request header identifying the client session for read-your-writes consistency.
+const SessionHeader = "X-Session-ID"
+
+// ReplicaDatabaseURLEnvVar is the environment variable holding the connection URL of the database replica.
+// Reads are served by primary when it is unset or empty.
+var ReplicaDatabaseURLEnvVar = strings.ToUpper(config.ConfigurationEnvVarPrefix) +
+	"_CLIENT_DATABASE_REPLICA_CONNECTION_URL"
+
+// ConsistentPools routes database reads to the replica, except for sessions that recently wrote, whose reads
+// are routed to the primary so that they observe their own writes despite replication lag.
+// Recent writes are tracked in cache so that consistency holds across instances.
+type ConsistentPools struct {
+	primary *pgxpool.Pool
+	replica *pgxpool.Pool
+	cache   valkey.Client
+	window  time.Duration
+}
+
+// NewConsistentPools returns pools routing reads of sessions to primary for window after their last write.
+// window should exceed the expected replication lag.
+func NewConsistentPools(
+	primary *pgxpool.Pool,
+	replica *pgxpool.Pool,
+	cache valkey.Client,
+	window time.Duration,
+) *ConsistentPools {
+	return &ConsistentPools{
+		primary: primary,
+		replica: replica,
+		cache:   cache,
+		window:  window,
+	}
+}
+
+func recentWriteKey(session string) string {
+	return "recent-write:" + session
+}
+
+// ForWrite returns the pool to use for writes, that is, the primary.
+func (p *ConsistentPools) ForWrite() *pgxpool.Pool {
+	return p.primary
+}
+
+// CheckReplica checks replica like [database.Check]. As replica is not used when it is primary, which is
+// checked on its own, false is returned in such case.
+func (p *ConsistentPools) CheckReplica(statusOnPingFail monitoring.Status) (monitoring.StatusCheck, bool) {
+	if p.replica == p.primary {
+		return monitoring.StatusCheck{}, false
+	}
+
+	return database.Check(p.replica, statusOnPingFail), true
+}
+
+// MarkWrite records that session just wrote, routing its reads to primary for the configured window.
+func (p *ConsistentPools) MarkWrite(ctx context.Context, session string) error {
+	if session == "" {
+		return nil
+	}
+
+	err := p.cache.Do(
+		ctx,
+		p.cache.B().Set().Key(recentWriteKey(session)).Value("1").Px(p.window).Build(),
+	).Error()
+	if err != nil {
+		return fmt.Errorf("error marking session write: %w", err)
+	}
+
+	return nil
+}
+
+// ForRead returns the pool to use for reads of session. Primary is returned if session wrote within the
+// configured window, or if it can't be determined.
+func (p *ConsistentPools) ForRead(ctx context.Context, session string) *pgxpool.Pool {
+	if session == "" {
+		return p.replica
+	}
+
+	err := p.cache.Do(ctx, p.cache.B().Get().Key(recentWriteKey(session)).Build()).Error()
+	if valkey.IsValkeyNil(err) {
+		return p.replica
+	}
+
+	if err != nil {
+		// Favor consistency over offloading primary
+		log.ErrLog(packageName, "error checking session write", err)
+	}
+
+	return p.primary
+}
+
+// NewExampleConsistentWriteHandler inserts a task, then routes reads of the session to primary.
+func NewExampleConsistentWriteHandler(pools *ConsistentPools, exec failsafe.Executor[any]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var id int
+
+		err := exec.Run(func() error {
+			return pools.ForWrite().QueryRow(
+				r.Context(),
+				`INSERT INTO tasks (created_at) VALUES ($1) RETURNING id`,
+				time.Now(),
+			).Scan(&id)
+		})
+		if err != nil {
+			log.ErrLog(packageName, "error database insert", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		err = pools.MarkWrite(r.Context(), r.Header.Get(SessionHeader))
+		if err != nil {
+			// Write succeeded, only consistency of subsequent reads is affected
+			log.ErrLog(packageName, "error marking session write", err)
+		}
+
+		type ExampleOutput struct {
+			ID int
+		}
+
+		resp.JSON(w, ExampleOutput{ID: id})
+	}
+}
+
+// NewExampleConsistentReadHandler reads latest task, observing writes of the session.
+func NewExampleConsistentReadHandler(pools *ConsistentPools, exec failsafe.Executor[any]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var id int
+
+		err := exec.Run(func() error {
+			return pools.ForRead(r.Context(), r.Header.Get(SessionHeader)).QueryRow(
+				r.Context(),
+				`SELECT id FROM tasks ORDER BY id DESC LIMIT 1`,
+			).Scan(&id)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.NotFound(w, r)
+
+				return
+			}
+
+			log.ErrLog(packageName, "error database select", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		type ExampleOutput struct {
+			ID int
+		}
+
+		resp.JSON(w, ExampleOutput{ID: id})
+	}
+}
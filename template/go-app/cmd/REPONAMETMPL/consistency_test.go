@@ -0,0 +1,104 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kemadev/go-framework/pkg/monitoring"
+)
+
+// newLazyPool returns a pool that is never connected, as connections are established on first use.
+func newLazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	pool, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func TestConsistentPools(t *testing.T) {
+	t.Parallel()
+
+	cacheServer, cacheClient := newFakeCache(t)
+
+	primary := newLazyPool(t)
+	replica := newLazyPool(t)
+
+	pools := NewConsistentPools(primary, replica, cacheClient, 5*time.Second)
+	ctx := context.Background()
+
+	if pools.ForWrite() != primary {
+		t.Errorf("expected writes to primary")
+	}
+
+	if pools.ForRead(ctx, "a") != replica {
+		t.Errorf("expected read before write to replica")
+	}
+
+	err := pools.MarkWrite(ctx, "a")
+	if err != nil {
+		t.Fatalf("MarkWrite: %s", err)
+	}
+
+	if pools.ForRead(ctx, "a") != primary {
+		t.Errorf("expected read right after write to primary")
+	}
+
+	if pools.ForRead(ctx, "b") != replica {
+		t.Errorf("expected read of other session to replica")
+	}
+
+	if pools.ForRead(ctx, "") != replica {
+		t.Errorf("expected read without session to replica")
+	}
+
+	cacheServer.FastForward(5 * time.Second)
+
+	if pools.ForRead(ctx, "a") != replica {
+		t.Errorf("expected read after window to replica")
+	}
+
+	// Favor consistency when writes can't be checked
+	cacheServer.SetError("unavailable")
+
+	if pools.ForRead(ctx, "a") != primary {
+		t.Errorf("expected read to primary when cache errors")
+	}
+}
+
+func TestConsistentPoolsCheckReplica(t *testing.T) {
+	t.Parallel()
+
+	_, cacheClient := newFakeCache(t)
+
+	primary := newLazyPool(t)
+
+	// Replica is not listening
+	check, ok := NewConsistentPools(primary, newLazyPool(t), cacheClient, time.Second).
+		CheckReplica(monitoring.StatusDown)
+	if !ok {
+		t.Fatalf("expected replica to be checked")
+	}
+
+	if check.Status != monitoring.StatusDown {
+		t.Errorf("expected status %s; got %s", monitoring.StatusDown, check.Status)
+	}
+
+	// Reads are served by primary, which is checked on its own
+	_, ok = NewConsistentPools(primary, primary, cacheClient, time.Second).CheckReplica(monitoring.StatusDown)
+	if ok {
+		t.Errorf("expected replica not to be checked without dedicated replica")
+	}
+}
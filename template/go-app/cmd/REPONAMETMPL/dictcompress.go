@@ -0,0 +1,203 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/encoding"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ZstdDictionaryHeader is the header used to negotiate dictionary compression. Clients send the
+// identifier of the dictionary they hold, server echoes it when a response is compressed with it.
+const ZstdDictionaryHeader = "X-Zstd-Dictionary-ID"
+
+// DictCompressConfig defines the configuration for dictionary compression middleware.
+type DictCompressConfig struct {
+	// Identifier of the dictionary, that clients must send in [ZstdDictionaryHeader] to opt in
+	ID uint32
+	// Raw content dictionary, typically a concatenation of representative JSON responses. Compression
+	// is disabled when empty
+	Dictionary []byte
+}
+
+// dictCompressResponseWriter compresses JSON responses with a zstd dictionary, passing other
+// responses through.
+type dictCompressResponseWriter struct {
+	http.ResponseWriter
+	encoder  *zstd.Encoder
+	id       string
+	head     bool
+	decided  bool
+	compress bool
+}
+
+// decide determines, once headers are known, whether response has to be compressed. Responses without
+// body are not, as closing encoder would write a frame.
+func (w *dictCompressResponseWriter) decide(statusCode int) {
+	if w.decided {
+		return
+	}
+
+	w.decided = true
+
+	if w.head ||
+		statusCode == http.StatusNoContent ||
+		statusCode == http.StatusNotModified ||
+		!headutil.IsMIME(w.Header(), headval.MIMEApplicationJSON) {
+		return
+	}
+
+	w.compress = true
+	w.encoder.Reset(w.ResponseWriter)
+
+	w.Header().Del(headkey.ContentLength)
+	w.Header().Set(headkey.ContentEncoding, headval.EncodingZstd)
+	w.Header().Set(ZstdDictionaryHeader, w.id)
+}
+
+// WriteHeader implements [net/http.ResponseWriter].
+func (w *dictCompressResponseWriter) WriteHeader(statusCode int) {
+	// Informational responses are followed by final one
+	if statusCode >= http.StatusOK {
+		w.decide(statusCode)
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements [net/http.ResponseWriter].
+func (w *dictCompressResponseWriter) Write(data []byte) (int, error) {
+	w.decide(http.StatusOK)
+
+	if !w.compress {
+		return w.ResponseWriter.Write(data)
+	}
+
+	n, err := w.encoder.Write(data)
+	if err != nil {
+		return n, fmt.Errorf("error writing dictionary compressed response: %w", err)
+	}
+
+	return n, nil
+}
+
+// Flush implements [net/http.Flusher].
+func (w *dictCompressResponseWriter) Flush() {
+	if w.compress {
+		err := w.encoder.Flush()
+		if err != nil {
+			log.ErrLog(packageName, "error flushing zstd writer", err)
+
+			return
+		}
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying [net/http.ResponseWriter].
+func (w *dictCompressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// NewDictCompressMiddleware returns a middleware that compresses JSON responses using zstd with a shared
+// dictionary, improving compression ratio of small, structurally similar responses. It is only applied to
+// requests accepting zstd and sending the dictionary identifier in [ZstdDictionaryHeader]. It takes
+// precedence over [encoding.CompressMiddleware], and must thus be registered before it.
+func NewDictCompressMiddleware(conf DictCompressConfig) (func(http.Handler) http.Handler, error) {
+	if len(conf.Dictionary) == 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}, nil
+	}
+
+	newEncoder := func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(
+			nil,
+			zstd.WithEncoderDictRaw(conf.ID, conf.Dictionary),
+			// Favor latency, responses are expected to be small
+			zstd.WithEncoderConcurrency(1),
+		)
+	}
+
+	// Validate dictionary upfront rather than on first request
+	_, err := newEncoder()
+	if err != nil {
+		return nil, fmt.Errorf("error creating zstd dictionary encoder: %w", err)
+	}
+
+	encoderPool := sync.Pool{
+		New: func() any {
+			encoder, err := newEncoder()
+			if err != nil {
+				return err
+			}
+
+			return encoder
+		},
+	}
+
+	id := strconv.FormatUint(uint64(conf.ID), 10)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add(headkey.Vary, ZstdDictionaryHeader)
+
+			if r.Header.Get(ZstdDictionaryHeader) != id ||
+				!headutil.AcceptsEncoding(r.Header, headval.EncodingZstd) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			pe := encoderPool.Get()
+
+			encoder, ok := pe.(*zstd.Encoder)
+			if !ok || encoder == nil {
+				log.ErrLog(packageName, "error getting compressor from pool", encoding.ErrFailureGetFromPool)
+				next.ServeHTTP(w, r)
+
+				return
+			}
+			defer encoderPool.Put(encoder)
+
+			dw := &dictCompressResponseWriter{
+				ResponseWriter: w,
+				encoder:        encoder,
+				id:             id,
+				head:           r.Method == http.MethodHead,
+			}
+
+			defer func() {
+				if !dw.compress {
+					return
+				}
+
+				err := encoder.Close()
+				if err != nil {
+					log.ErrLog(packageName, "error closing zstd writer", err)
+				}
+			}()
+
+			// Prevent further compression down the chain
+			r = r.Clone(r.Context())
+			r.Header.Del(headkey.AcceptEncoding)
+
+			next.ServeHTTP(dw, r)
+		})
+	}, nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/klauspost/compress/zstd"
+)
+
+const testDictID = 42
+
+// testDictPayload returns a small JSON document sharing structure with [testDictionary].
+func testDictPayload(i int) string {
+	return fmt.Sprintf(
+		`{"id":%d,"status":"pending","owner":{"name":"example","email":"example@example.com"},"tags":["alpha","beta"]}`,
+		i,
+	)
+}
+
+// testDictionary returns a raw dictionary built from representative payloads.
+func testDictionary() []byte {
+	var b strings.Builder
+
+	for i := range 50 {
+		b.WriteString(testDictPayload(i * 1000))
+	}
+
+	return []byte(b.String())
+}
+
+func TestDictCompressMiddleware(t *testing.T) {
+	t.Parallel()
+
+	dict := testDictionary()
+
+	mw, err := NewDictCompressMiddleware(DictCompressConfig{ID: testDictID, Dictionary: dict})
+	if err != nil {
+		t.Fatalf("NewDictCompressMiddleware: %s", err)
+	}
+
+	payload := testDictPayload(7)
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-content":
+			w.Header().Set(headkey.ContentType, headval.MIMEApplicationJSON)
+			w.WriteHeader(http.StatusNoContent)
+		case "/not-modified":
+			w.Header().Set(headkey.ContentType, headval.MIMEApplicationJSON)
+			w.WriteHeader(http.StatusNotModified)
+		case "/text":
+			w.Header().Set(headkey.ContentType, headval.MIMETextPlainCharsetUTF8)
+			_, _ = w.Write([]byte(payload))
+		default:
+			w.Header().Set(headkey.ContentType, headval.MIMEApplicationJSON)
+			_, _ = w.Write([]byte(payload))
+		}
+	}))
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDictRaw(testDictID, dict))
+	if err != nil {
+		t.Fatalf("NewReader: %s", err)
+	}
+	defer decoder.Close()
+
+	plainDecoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("NewReader: %s", err)
+	}
+	defer plainDecoder.Close()
+
+	// Baseline size of payload compressed without dictionary, with otherwise same settings
+	plainEncoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		t.Fatalf("NewWriter: %s", err)
+	}
+	defer plainEncoder.Close()
+
+	plainSize := len(plainEncoder.EncodeAll([]byte(payload), nil))
+
+	tests := []struct {
+		Name             string
+		Method           string
+		Path             string
+		DictID           string
+		ExpectedStatus   int
+		ExpectedEncoding string
+		ExpectedBody     string
+	}{
+		{
+			Name:             "compressed",
+			Method:           http.MethodGet,
+			Path:             "/",
+			DictID:           "42",
+			ExpectedStatus:   http.StatusOK,
+			ExpectedEncoding: headval.EncodingZstd,
+			ExpectedBody:     payload,
+		},
+		{
+			Name:           "unknown dictionary",
+			Method:         http.MethodGet,
+			Path:           "/",
+			DictID:         "1",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "not JSON",
+			Method:         http.MethodGet,
+			Path:           "/text",
+			DictID:         "42",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "head",
+			Method:         http.MethodHead,
+			Path:           "/",
+			DictID:         "42",
+			ExpectedStatus: http.StatusOK,
+			// Recorder keeps body of HEAD responses, which server would discard
+			ExpectedBody: payload,
+		},
+		{
+			Name:           "no content",
+			Method:         http.MethodGet,
+			Path:           "/no-content",
+			DictID:         "42",
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "not modified",
+			Method:         http.MethodGet,
+			Path:           "/not-modified",
+			DictID:         "42",
+			ExpectedStatus: http.StatusNotModified,
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.Method, test.Path, nil)
+		req.Header.Set(headkey.AcceptEncoding, headval.EncodingZstd)
+		req.Header.Set(ZstdDictionaryHeader, test.DictID)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		encoding := rr.Header().Get(headkey.ContentEncoding)
+		if encoding != test.ExpectedEncoding {
+			t.Errorf("%s: expected encoding %q; got %q", test.Name, test.ExpectedEncoding, encoding)
+		}
+
+		body := rr.Body.Bytes()
+
+		if encoding == headval.EncodingZstd {
+			if len(body) >= plainSize {
+				t.Errorf(
+					"%s: expected compressed size below %d without dictionary; got %d",
+					test.Name,
+					plainSize,
+					len(body),
+				)
+			}
+
+			_, err = plainDecoder.DecodeAll(body, nil)
+			if err == nil {
+				t.Errorf("%s: expected decoding without dictionary to fail", test.Name)
+			}
+
+			if rr.Header().Get(ZstdDictionaryHeader) != test.DictID {
+				t.Errorf(
+					"%s: expected dictionary %q; got %q",
+					test.Name,
+					test.DictID,
+					rr.Header().Get(ZstdDictionaryHeader),
+				)
+			}
+
+			body, err = decoder.DecodeAll(body, nil)
+			if err != nil {
+				t.Errorf("%s: DecodeAll: %s", test.Name, err)
+			}
+		}
+
+		if string(body) != test.ExpectedBody {
+			t.Errorf("%s: expected body %q; got %q", test.Name, test.ExpectedBody, body)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/encoding"
+	"github.com/klauspost/compress/zstd"
+)
+
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// DecompressEncodingsDefault are the request content encodings accepted by default.
+var DecompressEncodingsDefault = []string{
+	headval.EncodingGzip,
+	headval.EncodingBr,
+	headval.EncodingZstd,
+}
+
+// DecompressedSizeDefault is the default maximum size of decompressed request bodies.
+const DecompressedSizeDefault = 10 << 20
+
+// DecompressConfig defines the configuration for decompression middleware.
+type DecompressConfig struct {
+	// Content encodings that requests are allowed to use, others are rejected with 415. Only gzip,
+	// br and zstd are supported.
+	AllowedEncodings []string
+	// Maximum size of decompressed request bodies, protecting against decompression bombs. Zero
+	// uses [DecompressedSizeDefault]
+	MaxDecompressedSize int64
+}
+
+// zstdBodyReader reports decoder memory limit errors as [net/http.MaxBytesError], consistently with other
+// encodings.
+type zstdBodyReader struct {
+	dec   *zstd.Decoder
+	limit int64
+}
+
+func (r *zstdBodyReader) Read(p []byte) (int, error) {
+	n, err := r.dec.Read(p)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return n, &http.MaxBytesError{Limit: r.limit}
+	}
+
+	// Left unwrapped, as callers check for io.EOF
+	return n, err
+}
+
+// NewDecompressMiddleware returns a middleware that performs decompression of request body, rejecting
+// requests whose content encoding is not allowed by conf with [net/http.StatusUnsupportedMediaType].
+// Gzip decompression is delegated to [encoding.DecompressMiddleware]. Reading past the maximum decompressed
+// size fails with a [net/http.MaxBytesError].
+func NewDecompressMiddleware(conf DecompressConfig) (func(http.Handler) http.Handler, error) {
+	for _, enc := range conf.AllowedEncodings {
+		if !slices.Contains(DecompressEncodingsDefault, enc) {
+			return nil, fmt.Errorf("%q: %w", enc, ErrUnsupportedEncoding)
+		}
+	}
+
+	if conf.MaxDecompressedSize <= 0 {
+		conf.MaxDecompressedSize = DecompressedSizeDefault
+	}
+
+	allowed := strings.Join(conf.AllowedEncodings, ", ")
+
+	zstdPool := sync.Pool{
+		New: func() any {
+			// Decode synchronously, as concurrent decoding starts goroutines for each decoder
+			dec, err := zstd.NewReader(
+				nil,
+				zstd.WithDecoderConcurrency(1),
+				zstd.WithDecoderMaxMemory(uint64(conf.MaxDecompressedSize)),
+			)
+			if err != nil {
+				return nil
+			}
+
+			return dec
+		},
+	}
+
+	return func(next http.Handler) http.Handler {
+		// Bound decompressed body size
+		limited := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, conf.MaxDecompressedSize)
+
+			next.ServeHTTP(w, r)
+		})
+		gzipHandler := encoding.DecompressMiddleware(limited)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enc := strings.ToLower(strings.TrimSpace(r.Header.Get(headkey.ContentEncoding)))
+			if enc == "" || enc == headval.EncodingIdentity {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			// Stacked encodings (e.g. "gzip, br") are not supported, and thus not part of any allowlist
+			if !slices.Contains(conf.AllowedEncodings, enc) {
+				// Advertise accepted encodings, see RFC 7694
+				w.Header().Set(headkey.AcceptEncoding, allowed)
+				http.Error(
+					w,
+					http.StatusText(http.StatusUnsupportedMediaType),
+					http.StatusUnsupportedMediaType,
+				)
+
+				return
+			}
+
+			switch enc {
+			case headval.EncodingGzip:
+				// Delegate compares header verbatim
+				r.Header.Set(headkey.ContentEncoding, enc)
+
+				gzipHandler.ServeHTTP(w, r)
+			case headval.EncodingBr:
+				body := r.Body
+				defer body.Close()
+
+				r.Body = io.NopCloser(brotli.NewReader(body))
+
+				limited.ServeHTTP(w, r)
+			case headval.EncodingZstd:
+				body := r.Body
+				defer body.Close()
+
+				decompressReader, ok := zstdPool.Get().(*zstd.Decoder)
+				if !ok || decompressReader == nil {
+					log.ErrLog(packageName, "error getting body decompressor", encoding.ErrFailureGetFromPool)
+					http.Error(
+						w,
+						http.StatusText(http.StatusServiceUnavailable),
+						http.StatusServiceUnavailable,
+					)
+
+					return
+				}
+
+				err := decompressReader.Reset(body)
+				if err != nil {
+					log.ErrLog(packageName, "error resetting body decompressor", err)
+					http.Error(
+						w,
+						http.StatusText(http.StatusServiceUnavailable),
+						http.StatusServiceUnavailable,
+					)
+
+					return
+				}
+
+				defer func() {
+					// Release body before returning decoder to pool
+					_ = decompressReader.Reset(nil)
+					zstdPool.Put(decompressReader)
+				}()
+
+				r.Body = io.NopCloser(&zstdBodyReader{
+					dec:   decompressReader,
+					limit: conf.MaxDecompressedSize,
+				})
+
+				limited.ServeHTTP(w, r)
+			}
+		})
+	}, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compressBody(t *testing.T, enc string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	var w io.WriteCloser
+
+	switch enc {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("zstd.NewWriter: %s", err)
+		}
+
+		w = zw
+	default:
+		return data
+	}
+
+	_, err := w.Write(data)
+	if err != nil {
+		t.Fatalf("compress %s: %s", enc, err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("compress %s: %s", enc, err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecompressMiddleware(t *testing.T) {
+	t.Parallel()
+
+	const payload = `{"hello":"world"}`
+
+	mw, err := NewDecompressMiddleware(DecompressConfig{
+		AllowedEncodings:    DecompressEncodingsDefault,
+		MaxDecompressedSize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("NewDecompressMiddleware: %s", err)
+	}
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(
+					w,
+					http.StatusText(http.StatusRequestEntityTooLarge),
+					http.StatusRequestEntityTooLarge,
+				)
+
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = w.Write(body)
+	}))
+
+	tests := []struct {
+		Name           string
+		Encoding       string
+		Header         string
+		Payload        string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "identity",
+			Header:         "",
+			Payload:        payload,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "gzip",
+			Encoding:       "gzip",
+			Header:         "gzip",
+			Payload:        payload,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "gzip not normalized",
+			Encoding:       "gzip",
+			Header:         "GZIP ",
+			Payload:        payload,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "br",
+			Encoding:       "br",
+			Header:         "br",
+			Payload:        payload,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "zstd",
+			Encoding:       "zstd",
+			Header:         "zstd",
+			Payload:        payload,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   payload,
+		},
+		{
+			Name:           "zstd too large",
+			Encoding:       "zstd",
+			Header:         "zstd",
+			Payload:        strings.Repeat("a", 2048),
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:           "gzip too large",
+			Encoding:       "gzip",
+			Header:         "gzip",
+			Payload:        strings.Repeat("a", 2048),
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:           "unsupported",
+			Header:         "deflate",
+			Payload:        payload,
+			ExpectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			Name:           "stacked",
+			Header:         "gzip, br",
+			Payload:        payload,
+			ExpectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				bytes.NewReader(compressBody(t, test.Encoding, []byte(test.Payload))),
+			)
+			if test.Header != "" {
+				req.Header.Set(headkey.ContentEncoding, test.Header)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.ExpectedStatus {
+				t.Errorf("expected status %d; got %d", test.ExpectedStatus, rr.Code)
+			}
+
+			if test.ExpectedBody != "" && rr.Body.String() != test.ExpectedBody {
+				t.Errorf("expected body %q; got %q", test.ExpectedBody, rr.Body.String())
+			}
+
+			if test.ExpectedStatus == http.StatusUnsupportedMediaType &&
+				rr.Header().Get(headkey.AcceptEncoding) != "gzip, br, zstd" {
+				t.Errorf(
+					"expected accepted encodings %q; got %q",
+					"gzip, br, zstd",
+					rr.Header().Get(headkey.AcceptEncoding),
+				)
+			}
+		})
+	}
+}
+
+func TestDecompressMiddlewareConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewDecompressMiddleware(DecompressConfig{
+		AllowedEncodings: []string{"deflate"},
+	})
+	if !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("expected %s; got %v", ErrUnsupportedEncoding, err)
+	}
+}
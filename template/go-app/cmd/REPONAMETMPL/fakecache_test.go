@@ -0,0 +1,33 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/valkey-io/valkey-go"
+)
+
+// newFakeCache returns a client of an in-memory cache server, both closed at the end of the test.
+func newFakeCache(t *testing.T) (*miniredis.Miniredis, valkey.Client) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+
+	client, err := valkey.NewClient(valkey.ClientOption{
+		InitAddress: []string{server.Addr()},
+		// Client side caching is not supported by server
+		DisableCache: true,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %s", err)
+	}
+
+	t.Cleanup(client.Close)
+
+	return server, client
+}
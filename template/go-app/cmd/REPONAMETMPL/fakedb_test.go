@@ -0,0 +1,189 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeStatement is a statement recorded by fakeDB.
+type fakeStatement struct {
+	SQL  string
+	Args []any
+}
+
+// fakeDB records statements of transactions it begins, with savepoints for nested transactions. It
+// satisfies [TxBeginner] and [Querier], unimplemented [pgx.Tx] methods panic.
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []fakeStatement
+	// execErr returns the error of an Exec, nil always succeeds
+	execErr func(sql string, args []any) error
+	// row returns the values scanned by a QueryRow, nil scans nothing
+	row func(sql string, args []any) ([]any, error)
+	// rows returns the rows of a Query, nil returns none
+	rows func(sql string, args []any) ([][]any, error)
+}
+
+func (db *fakeDB) record(sql string, args ...any) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.statements = append(db.statements, fakeStatement{SQL: sql, Args: args})
+}
+
+// SQL returns recorded statements, without their arguments.
+func (db *fakeDB) SQL() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	sql := make([]string, 0, len(db.statements))
+	for _, statement := range db.statements {
+		sql = append(sql, statement.SQL)
+	}
+
+	return sql
+}
+
+func (db *fakeDB) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	db.record(sql, args...)
+
+	if db.rows == nil {
+		return &fakeRows{}, nil
+	}
+
+	values, err := db.rows(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fakeRows{values: values}, nil
+}
+
+func (db *fakeDB) Begin(_ context.Context) (pgx.Tx, error) {
+	db.record("BEGIN")
+
+	return &fakeTx{db: db}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDB
+	nested bool
+	closed bool
+}
+
+func (tx *fakeTx) Begin(_ context.Context) (pgx.Tx, error) {
+	tx.db.record("SAVEPOINT")
+
+	return &fakeTx{db: tx.db, nested: true}, nil
+}
+
+func (tx *fakeTx) Commit(_ context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+
+	tx.closed = true
+
+	if tx.nested {
+		tx.db.record("RELEASE SAVEPOINT")
+	} else {
+		tx.db.record("COMMIT")
+	}
+
+	return nil
+}
+
+func (tx *fakeTx) Rollback(_ context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+
+	tx.closed = true
+
+	if tx.nested {
+		tx.db.record("ROLLBACK TO SAVEPOINT")
+	} else {
+		tx.db.record("ROLLBACK")
+	}
+
+	return nil
+}
+
+func (tx *fakeTx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.db.record(sql, args...)
+
+	if tx.db.execErr != nil {
+		return pgconn.CommandTag{}, tx.db.execErr(sql, args)
+	}
+
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	tx.db.record(sql, args...)
+
+	if tx.db.row == nil {
+		return fakeRow{}
+	}
+
+	values, err := tx.db.row(sql, args)
+
+	return fakeRow{values: values, err: err}
+}
+
+type fakeRow struct {
+	values []any
+	err    error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for i := range min(len(dest), len(r.values)) {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(r.values[i]))
+	}
+
+	return nil
+}
+
+// fakeRows iterates over values, unimplemented [pgx.Rows] methods panic.
+type fakeRows struct {
+	pgx.Rows
+	values  [][]any
+	current int
+	closed  bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.closed || r.current >= len(r.values) {
+		return false
+	}
+
+	r.current++
+
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	return fakeRow{values: r.values[r.current-1]}.Scan(dest...)
+}
+
+func (r *fakeRows) Close() {
+	r.closed = true
+}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/kemadev/go-framework/pkg/config"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HedgeDelayDefault is the default delay before hedging a call.
+const HedgeDelayDefault = 200 * time.Millisecond
+
+// HedgeDelayEnvVar is the environment variable holding the delay before hedging a call, in [time.ParseDuration]
+// format. It should be around the dependency's p95 latency, [HedgeDelayDefault] is used when it is unset or empty.
+var HedgeDelayEnvVar = strings.ToUpper(config.ConfigurationEnvVarPrefix) + "_CLIENT_HEDGE_DELAY"
+
+var ErrInvalidHedgeDelay = errors.New("hedge delay must be positive")
+
+// LoadHedgeDelay returns the delay held by [HedgeDelayEnvVar].
+func LoadHedgeDelay() (time.Duration, error) {
+	value := os.Getenv(HedgeDelayEnvVar)
+	if value == "" {
+		return HedgeDelayDefault, nil
+	}
+
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing hedge delay: %w", err)
+	}
+
+	if delay <= 0 {
+		return 0, fmt.Errorf("%s: %w", delay, ErrInvalidHedgeDelay)
+	}
+
+	return delay, nil
+}
+
+// NewExampleHedgedHandler calls url through exec, that is expected to hold a hedge policy.
+// Each attempt uses its execution context, so that the slowest attempt is canceled once the fastest one
+// completes.
+func NewExampleHedgedHandler(exec failsafe.Executor[any], url string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eresp, err := exec.WithContext(r.Context()).GetWithExecution(func(e failsafe.Execution[any]) (any, error) {
+			res, err := otelhttp.Get(e.Context(), url)
+			if err != nil {
+				return nil, err
+			}
+
+			// Losing attempts are canceled and their result discarded, release their connection. Context of
+			// winning attempt is only done once request is, its body being closed by then
+			context.AfterFunc(e.Context(), func() {
+				res.Body.Close()
+			})
+
+			return res, nil
+		})
+		if err != nil {
+			log.ErrLog(packageName, "error calling external http endpoint", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		res := eresp.(*http.Response)
+		defer res.Body.Close()
+
+		type ExampleOutput struct {
+			StatusCode int
+		}
+
+		resp.JSON(w, ExampleOutput{
+			StatusCode: res.StatusCode,
+		})
+	}
+}
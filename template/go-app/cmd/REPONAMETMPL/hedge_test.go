@@ -0,0 +1,123 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/failsafe-go/failsafe-go/hedgepolicy"
+)
+
+func TestExampleHedgedHandler(t *testing.T) {
+	t.Parallel()
+
+	const slowDelay = time.Second
+
+	tests := []struct {
+		Name       string
+		HedgeDelay time.Duration
+		// Whether latency is expected to be below slowDelay
+		ExpectedFast bool
+	}{
+		{Name: "hedged", HedgeDelay: 50 * time.Millisecond, ExpectedFast: true},
+		{Name: "hedge after slow attempt", HedgeDelay: 2 * slowDelay, ExpectedFast: false},
+	}
+
+	for _, test := range tests {
+		var (
+			calls    atomic.Int32
+			canceled = make(chan struct{}, 1)
+		)
+
+		// First call is slow, subsequent ones are fast
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) > 1 {
+				w.WriteHeader(http.StatusAccepted)
+
+				return
+			}
+
+			select {
+			case <-time.After(slowDelay):
+				w.WriteHeader(http.StatusOK)
+			case <-r.Context().Done():
+				canceled <- struct{}{}
+			}
+		}))
+
+		exec := failsafe.With[any](hedgepolicy.NewWithDelay[any](test.HedgeDelay))
+		handler := NewExampleHedgedHandler(exec, upstream.URL)
+
+		rr := httptest.NewRecorder()
+		start := time.Now()
+
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/hedged", nil))
+
+		latency := time.Since(start)
+
+		if test.ExpectedFast != (latency < slowDelay) {
+			t.Errorf("%s: expected fast response %t; got latency %s", test.Name, test.ExpectedFast, latency)
+		}
+
+		expectedBody := `{"StatusCode":200}`
+		if test.ExpectedFast {
+			expectedBody = `{"StatusCode":202}`
+
+			// Slow attempt is canceled once fast one completes
+			select {
+			case <-canceled:
+			case <-time.After(slowDelay):
+				t.Errorf("%s: expected slow attempt to be canceled", test.Name)
+			}
+		}
+
+		if strings.TrimSpace(rr.Body.String()) != expectedBody {
+			t.Errorf("%s: expected body %q; got %q", test.Name, expectedBody, rr.Body.String())
+		}
+
+		upstream.Close()
+	}
+}
+
+func TestLoadHedgeDelay(t *testing.T) {
+	tests := []struct {
+		Value         string
+		ExpectedDelay time.Duration
+		ExpectedError error
+	}{
+		{Value: "", ExpectedDelay: HedgeDelayDefault},
+		{Value: "75ms", ExpectedDelay: 75 * time.Millisecond},
+		{Value: "0s", ExpectedError: ErrInvalidHedgeDelay},
+		{Value: "-1s", ExpectedError: ErrInvalidHedgeDelay},
+	}
+
+	for _, test := range tests {
+		t.Setenv(HedgeDelayEnvVar, test.Value)
+
+		delay, err := LoadHedgeDelay()
+		if !errors.Is(err, test.ExpectedError) {
+			t.Errorf("%q: expected error %v; got %v", test.Value, test.ExpectedError, err)
+		}
+
+		if delay != test.ExpectedDelay {
+			t.Errorf("%q: expected delay %s; got %s", test.Value, test.ExpectedDelay, delay)
+		}
+	}
+
+	t.Setenv(HedgeDelayEnvVar, "soon")
+
+	_, err := LoadHedgeDelay()
+	if err == nil {
+		t.Errorf("%q: expected error; got nil", "soon")
+	}
+}
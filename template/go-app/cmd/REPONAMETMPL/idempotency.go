@@ -0,0 +1,220 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyMaxEntriesDefault is the default maximum number of recorded responses.
+const IdempotencyMaxEntriesDefault = 10000
+
+// IdempotencyConfig defines the configuration for idempotency middleware.
+type IdempotencyConfig struct {
+	// How long a completed response is replayed for requests reusing its key
+	TTL time.Duration
+	// How long a request waits for an in-flight request with the same key to complete
+	WaitTimeout time.Duration
+	// Maximum number of recorded responses, oldest ones are evicted beyond it. Zero uses
+	// [IdempotencyMaxEntriesDefault]
+	MaxEntries int
+}
+
+// idempotentResponse is a response recorded for replay.
+type idempotentResponse struct {
+	header     http.Header
+	statusCode int
+	body       []byte
+}
+
+// idempotencyEntry tracks a request for a given key. done is closed once response is recorded, or once
+// request failed without response.
+type idempotencyEntry struct {
+	key       string
+	done      chan struct{}
+	failed    bool
+	response  idempotentResponse
+	expiresAt time.Time
+}
+
+// recordingResponseWriter writes through to the client while recording the response.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	buffer     bytes.Buffer
+	statusCode int
+}
+
+func (rw *recordingResponseWriter) WriteHeader(statusCode int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = statusCode
+	}
+
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+
+	rw.buffer.Write(b)
+
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// NewIdempotencyMiddleware returns a middleware that executes requests carrying an [IdempotencyKeyHeader]
+// at most once per key, replaying the recorded response for subsequent requests. Requests arriving while
+// the first one is still in flight wait for its response, and are rejected with 409 if it does not complete
+// within [IdempotencyConfig.WaitTimeout]. Server errors are not kept, so that clients can retry, and should
+// the first request panic, waiting requests compete to execute instead.
+// Keys are tracked in memory, hence per instance.
+func NewIdempotencyMiddleware(conf IdempotencyConfig) func(http.Handler) http.Handler {
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = IdempotencyMaxEntriesDefault
+	}
+
+	var mu sync.Mutex
+
+	entries := make(map[string]*idempotencyEntry)
+	// Completed entries, oldest first. As TTL is the same for all entries, it is also expiration order
+	completed := list.New()
+
+	// evict drops expired entries, and oldest ones beyond limit. mu must be held.
+	evict := func(now time.Time) {
+		for elem := completed.Front(); elem != nil; elem = completed.Front() {
+			entry := elem.Value.(*idempotencyEntry)
+			if completed.Len() <= conf.MaxEntries && now.Before(entry.expiresAt) {
+				break
+			}
+
+			completed.Remove(elem)
+			delete(entries, entry.key)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			// Scope keys to the operation they were issued for
+			key = r.Method + " " + r.URL.Path + " " + key
+
+			waitTimer := time.NewTimer(conf.WaitTimeout)
+			defer waitTimer.Stop()
+
+			var entry *idempotencyEntry
+
+			for entry == nil {
+				mu.Lock()
+
+				evict(time.Now())
+
+				existing, inFlightOrDone := entries[key]
+				if !inFlightOrDone {
+					entry = &idempotencyEntry{key: key, done: make(chan struct{})}
+					entries[key] = entry
+				}
+
+				mu.Unlock()
+
+				if !inFlightOrDone {
+					break
+				}
+
+				select {
+				case <-existing.done:
+					// Compete to execute request again
+					if existing.failed {
+						continue
+					}
+
+					replayIdempotentResponse(w, existing.response)
+				case <-waitTimer.C:
+					http.Error(
+						w,
+						http.StatusText(http.StatusConflict),
+						http.StatusConflict,
+					)
+				case <-r.Context().Done():
+					http.Error(
+						w,
+						http.StatusText(http.StatusServiceUnavailable),
+						http.StatusServiceUnavailable,
+					)
+				}
+
+				return
+			}
+
+			rw := &recordingResponseWriter{ResponseWriter: w}
+			returned := false
+
+			defer func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				defer close(entry.done)
+
+				// Handler panicked, response is unknown
+				if !returned {
+					entry.failed = true
+					delete(entries, key)
+
+					return
+				}
+
+				// Handler returned without writing, which is sent as an empty 200
+				if rw.statusCode == 0 {
+					rw.statusCode = http.StatusOK
+				}
+
+				entry.response = idempotentResponse{
+					header:     w.Header().Clone(),
+					statusCode: rw.statusCode,
+					body:       rw.buffer.Bytes(),
+				}
+
+				// Let waiters get the response, yet let later requests retry
+				if rw.statusCode >= http.StatusInternalServerError {
+					delete(entries, key)
+
+					return
+				}
+
+				entry.expiresAt = time.Now().Add(conf.TTL)
+				completed.PushBack(entry)
+				evict(time.Now())
+			}()
+
+			next.ServeHTTP(rw, r)
+
+			returned = true
+		})
+	}
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, res idempotentResponse) {
+	maps.Copy(w.Header(), res.header)
+	w.WriteHeader(res.statusCode)
+	_, _ = w.Write(res.body)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveIdempotent sends a request with idempotency key to handler, returning recorded response.
+func serveIdempotent(handler http.Handler, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	req.Header.Set(IdempotencyKeyHeader, key)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestIdempotencyMiddlewareConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := NewIdempotencyMiddleware(IdempotencyConfig{
+		TTL:         time.Minute,
+		WaitTimeout: 5 * time.Second,
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+
+		close(started)
+		<-release
+
+		w.Header().Set("X-Call", strconv.Itoa(int(n)))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+
+	var (
+		wg        sync.WaitGroup
+		first     *httptest.ResponseRecorder
+		duplicate *httptest.ResponseRecorder
+	)
+
+	wg.Go(func() {
+		first = serveIdempotent(handler, "k")
+	})
+
+	<-started
+
+	wg.Go(func() {
+		duplicate = serveIdempotent(handler, "k")
+	})
+
+	// Let duplicate wait on in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected handler to run %d times; got %d", 1, calls.Load())
+	}
+
+	for name, rr := range map[string]*httptest.ResponseRecorder{"first": first, "duplicate": duplicate} {
+		if rr.Code != http.StatusCreated {
+			t.Errorf("%s: expected status %d; got %d", name, http.StatusCreated, rr.Code)
+		}
+
+		if rr.Body.String() != "created" {
+			t.Errorf("%s: expected body %q; got %q", name, "created", rr.Body.String())
+		}
+
+		if rr.Header().Get("X-Call") != "1" {
+			t.Errorf("%s: expected header %q; got %q", name, "1", rr.Header().Get("X-Call"))
+		}
+	}
+}
+
+func TestIdempotencyMiddlewarePanic(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := NewIdempotencyMiddleware(IdempotencyConfig{
+		TTL:         time.Minute,
+		WaitTimeout: 5 * time.Second,
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+
+			panic(http.ErrAbortHandler)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	var (
+		wg        sync.WaitGroup
+		recovered any
+		duplicate *httptest.ResponseRecorder
+	)
+
+	wg.Go(func() {
+		defer func() {
+			recovered = recover()
+		}()
+
+		serveIdempotent(handler, "k")
+	})
+
+	<-started
+
+	wg.Go(func() {
+		duplicate = serveIdempotent(handler, "k")
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if recovered != http.ErrAbortHandler {
+		t.Errorf("expected panic to propagate; got %v", recovered)
+	}
+
+	// Waiter executes request instead of replaying an empty success
+	if calls.Load() != 2 {
+		t.Errorf("expected handler to run %d times; got %d", 2, calls.Load())
+	}
+
+	if duplicate.Code != http.StatusCreated {
+		t.Errorf("expected status %d; got %d", http.StatusCreated, duplicate.Code)
+	}
+}
+
+func TestIdempotencyMiddlewareReplay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name           string
+		Status         int
+		ExpectedStatus int
+		ExpectedCalls  int32
+	}{
+		{Name: "success", Status: http.StatusCreated, ExpectedStatus: http.StatusCreated, ExpectedCalls: 1},
+		{Name: "client error", Status: http.StatusBadRequest, ExpectedStatus: http.StatusBadRequest, ExpectedCalls: 1},
+		{Name: "nothing written", Status: 0, ExpectedStatus: http.StatusOK, ExpectedCalls: 1},
+		{Name: "server error", Status: http.StatusBadGateway, ExpectedStatus: http.StatusBadGateway, ExpectedCalls: 2},
+	}
+
+	for _, test := range tests {
+		var calls atomic.Int32
+
+		handler := NewIdempotencyMiddleware(IdempotencyConfig{
+			TTL:         time.Minute,
+			WaitTimeout: time.Second,
+		})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+
+			if test.Status != 0 {
+				w.WriteHeader(test.Status)
+			}
+		}))
+
+		for range 2 {
+			rr := serveIdempotent(handler, "k")
+			if rr.Code != test.ExpectedStatus {
+				t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+			}
+		}
+
+		if calls.Load() != test.ExpectedCalls {
+			t.Errorf("%s: expected handler to run %d times; got %d", test.Name, test.ExpectedCalls, calls.Load())
+		}
+	}
+}
+
+func TestIdempotencyMiddlewareEviction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name          string
+		Conf          IdempotencyConfig
+		Wait          time.Duration
+		ExpectedCalls int32
+	}{
+		{
+			Name:          "within limits",
+			Conf:          IdempotencyConfig{TTL: time.Minute, MaxEntries: 3},
+			ExpectedCalls: 3,
+		},
+		{
+			Name:          "over max entries",
+			Conf:          IdempotencyConfig{TTL: time.Minute, MaxEntries: 2},
+			ExpectedCalls: 4,
+		},
+		{
+			Name:          "expired",
+			Conf:          IdempotencyConfig{TTL: 10 * time.Millisecond, MaxEntries: 3},
+			Wait:          50 * time.Millisecond,
+			ExpectedCalls: 6,
+		},
+	}
+
+	for _, test := range tests {
+		var calls atomic.Int32
+
+		test.Conf.WaitTimeout = time.Second
+
+		handler := NewIdempotencyMiddleware(test.Conf)(
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusCreated)
+			}),
+		)
+
+		for _, key := range []string{"a", "b", "c"} {
+			serveIdempotent(handler, key)
+		}
+
+		time.Sleep(test.Wait)
+
+		// Oldest key is the only one evicted when over capacity, replay it last as it evicts another one
+		for _, key := range []string{"c", "b", "a"} {
+			serveIdempotent(handler, key)
+		}
+
+		if calls.Load() != test.ExpectedCalls {
+			t.Errorf("%s: expected handler to run %d times; got %d", test.Name, test.ExpectedCalls, calls.Load())
+		}
+	}
+}
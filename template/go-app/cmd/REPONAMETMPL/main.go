@@ -10,12 +10,14 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"time"
 
-	"github.com/dgraph-io/ristretto/v2"
 	"github.com/failsafe-go/failsafe-go"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kemadev/REPONAMETMPL/web"
 	"github.com/kemadev/go-framework/pkg/client/cache"
 	"github.com/kemadev/go-framework/pkg/client/database"
 	"github.com/kemadev/go-framework/pkg/client/search"
@@ -35,7 +37,6 @@ import (
 	"github.com/kemadev/go-framework/pkg/router"
 	"github.com/kemadev/go-framework/pkg/server"
 	"github.com/kemadev/go-framework/pkg/timeout"
-	"github.com/kemadev/go-framework/web"
 	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/valkey-io/valkey-go"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -68,20 +69,68 @@ func main() {
 	}
 	defer databaseClient.Close()
 
+	// Serve reads from primary unless a replica is configured
+	replicaDatabaseClient := databaseClient
+
+	if replicaURL := os.Getenv(ReplicaDatabaseURLEnvVar); replicaURL != "" {
+		u, err := url.Parse(replicaURL)
+		if err != nil {
+			flog.FallbackError(fmt.Errorf("error parsing database replica url: %w", err))
+			os.Exit(1)
+		}
+
+		replicaDatabaseClient, err = database.NewClient(config.DatabaseConfig{ConnectionURL: *u})
+		if err != nil {
+			flog.FallbackError(err)
+			os.Exit(1)
+		}
+		defer replicaDatabaseClient.Close()
+	}
+
 	searchClient, err := search.NewClient(conf.Client.Search, conf.Runtime)
 	if err != nil {
 		flog.FallbackError(err)
 		os.Exit(1)
 	}
 
+	// Log requests, overriding level of noisy routes and routes needing details
+	accessLogConf := AccessLogConfig{
+		Level: slog.LevelInfo,
+		RouteLevels: map[string]slog.Level{
+			monitoring.HTTPLivenessCheckPattern:  slog.LevelWarn,
+			monitoring.HTTPReadinessCheckPattern: slog.LevelWarn,
+			"GET /foo/{bar}":                     slog.LevelDebug,
+		},
+	}
+
 	r := router.New()
 
+	r.Use(NewAccessLogMiddleware(r, accessLogConf))
+
 	// Always protect your routes (you can further customize at handler / group level)
 	r.Use(timeout.NewMiddleware(5 * time.Second))
 	r.Use(maxbytes.NewMiddleware(100000))
 
 	// Add other middlewares
-	r.Use(encoding.DecompressMiddleware)
+	decompressMiddleware, err := NewDecompressMiddleware(DecompressConfig{
+		// Restrict to the encodings your clients actually use
+		AllowedEncodings: DecompressEncodingsDefault,
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	r.Use(decompressMiddleware)
+
+	// Opt-in zstd dictionary compression for JSON responses, disabled until a dictionary is set
+	dictCompressMiddleware, err := NewDictCompressMiddleware(DictCompressConfig{})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	r.Use(dictCompressMiddleware)
 	r.Use(encoding.CompressMiddleware)
 
 	// Add monitoring endpoints
@@ -94,17 +143,32 @@ func main() {
 			conf,
 		),
 	)
+	// Route reads of sessions that just wrote to primary
+	consistentPools := NewConsistentPools(databaseClient, replicaDatabaseClient, cacheClient, 5*time.Second)
+
 	r.Handle(
 		monitoring.ReadinessHandler(
-			func() monitoring.CheckResults {
-				return monitoring.CheckResults{
-					// Adjust status on ping fail
-					"database": database.Check(databaseClient, monitoring.StatusDown),
-					"cache":    cache.Check(cacheClient, monitoring.StatusDown),
-					"search":   search.Check(searchClient, monitoring.StatusDown),
-					// Add your check functions
-				}
-			},
+			// Reuse results of recent checks, so that frequent probes don't hammer dependencies
+			NewCachedChecker(
+				func() monitoring.CheckResults {
+					checks := monitoring.CheckResults{
+						// Adjust status on ping fail
+						"database": database.Check(databaseClient, monitoring.StatusDown),
+						"cache":    cache.Check(cacheClient, monitoring.StatusDown),
+						"search":   search.Check(searchClient, monitoring.StatusDown),
+						// Add your check functions
+					}
+
+					// Reads are served by replica, if any
+					replicaCheck, ok := consistentPools.CheckReplica(monitoring.StatusDown)
+					if ok {
+						checks["database_replica"] = replicaCheck
+					}
+
+					return checks
+				},
+				time.Second,
+			),
 		),
 	)
 
@@ -115,11 +179,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create a caching backend (shared backend is also available)
-	cacheBackend, err := cache.NewFailsafeLocal(ristretto.Config[string, any]{
-		NumCounters: 100,
-		MaxCost:     100,
-		BufferItems: 64,
+	// Create a caching backend, bounding distinct keys so that high-cardinality keys can't exhaust memory
+	// (ristretto-based local and valkey-based shared backends are also available)
+	cacheBackend, err := NewBoundedCache[any](BoundedCacheConfig{
+		MaxKeysPerNamespace: 1000,
+		MaxKeys:             10000,
+		MaxMetricNamespaces: 50,
 	})
 	if err != nil {
 		flog.FallbackError(err)
@@ -128,34 +193,153 @@ func main() {
 
 	// Use otelfailsafe to create failsafe executor / policies, so these are automatically instrumented.
 	// This policy is arbitrary and should be tailored to your needs
-	exec := pe.NewExecutor(pe.NewRetryBuilder().WithJitterFactor(.25).Build(), pe.NewCacheBuilder(cacheBackend).Build())
+	newExecutor := func() failsafe.Executor[any] {
+		// Back off exponentially across executions, resetting once the dependency recovered. Maximum delay
+		// stays below request timeout, so that retries can complete
+		backoff := NewResettingBackoff[any](ResettingBackoffConfig{
+			Delay:       50 * time.Millisecond,
+			MaxDelay:    time.Second,
+			ResetWindow: 30 * time.Second,
+		})
+
+		return pe.NewExecutor(
+			backoff.Configure(pe.NewRetryBuilder().WithJitterFactor(.25)).Build(),
+			pe.NewCacheBuilder(cacheBackend).Build(),
+		)
+	}
+
+	// Use an executor per dependency, so that a failing one does not stretch backoff of others
+	httpExec := newExecutor()
+	cacheExec := newExecutor()
+	databaseExec := newExecutor()
+	searchExec := newExecutor()
 
 	// Add handlers
 	r.Handle(
-		otel.WrapHandler("GET /foo/{bar}", NewExampleHandler(exec)),
+		otel.WrapHandler("GET /foo/{bar}", NewExampleHandler(httpExec)),
+	)
+
+	// Hedge latency-sensitive reads
+	hedgeDelay, err := LoadHedgeDelay()
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	hedgeExec := pe.NewExecutor(pe.NewHedgeWithDelayBuilder(hedgeDelay).Build())
+
+	r.Handle(
+		otel.WrapHandler("GET /hedged", NewExampleHedgedHandler(hedgeExec, "https://example.com")),
 	)
 
 	r.Handle(
 		otel.WrapHandler(
 			"GET /cache",
-			NewExampleCacheHandler(cacheClient, exec),
+			NewExampleCacheHandler(cacheClient, cacheExec),
 		),
 	)
 
 	r.Handle(
 		otel.WrapHandler(
 			"GET /database",
-			NewExampleDatabaseHandler(databaseClient, exec),
+			NewExampleDatabaseHandler(databaseClient, databaseExec),
+		),
+	)
+
+	r.Handle(
+		otel.WrapHandler(
+			"GET /database/list",
+			NewExampleDatabaseListHandler(databaseClient, databaseExec, QueryRowLimitDefault),
+		),
+	)
+
+	r.Handle(
+		otel.WrapHandler(
+			"POST /database/batch",
+			NewExampleBatchInsertHandler(databaseClient, 500),
+		),
+	)
+
+	// Make non-idempotent operations safe to retry
+	r.Group(func(r *router.Router) {
+		r.Use(NewIdempotencyMiddleware(IdempotencyConfig{
+			TTL:         24 * time.Hour,
+			WaitTimeout: 3 * time.Second,
+			MaxEntries:  IdempotencyMaxEntriesDefault,
+		}))
+
+		r.Handle(
+			otel.WrapHandler(
+				"POST /database",
+				NewExampleDatabaseHandler(databaseClient, databaseExec),
+			),
+		)
+	})
+
+	r.Handle(
+		otel.WrapHandler(
+			"POST /consistent/database",
+			NewExampleConsistentWriteHandler(consistentPools, databaseExec),
+		),
+	)
+
+	r.Handle(
+		otel.WrapHandler(
+			"GET /consistent/database",
+			NewExampleConsistentReadHandler(consistentPools, databaseExec),
 		),
 	)
 
 	r.Handle(
 		otel.WrapHandler(
 			"GET /search",
-			NewExampleSearchHandler(searchClient, exec),
+			NewExampleSearchHandler(searchClient, searchExec),
 		),
 	)
 
+	// Back off and retry only bulk items rejected by backpressure
+	bulkExec := pe.NewExecutor(
+		pe.NewRetryBuilder().
+			HandleErrors(ErrBulkRejected).
+			WithBackoff(100*time.Millisecond, 5*time.Second).
+			WithJitterFactor(.25).
+			WithMaxRetries(5).
+			Build(),
+	)
+
+	r.Handle(
+		otel.WrapHandler(
+			"POST /search/bulk",
+			NewExampleSearchBulkHandler(searchClient, bulkExec),
+		),
+	)
+
+	// Isolate tenants in their own database schema
+	r.Group(func(r *router.Router) {
+		r.Use(NewTenantMiddleware(TenantHeader))
+
+		r.Handle(
+			otel.WrapHandler(
+				"GET /tenant/database",
+				NewExampleTenantDatabaseHandler(databaseClient, "tenant_"),
+			),
+		)
+	})
+
+	// Internal routes are protected with mTLS, which requires TLS to be terminated by the server. As
+	// [server.Run] serves plain HTTP only, client certificates are never presented, hence internal routes are
+	// not served. Serve them from a TLS server requesting client certificates to enable them
+	if os.Getenv(ClientCertRootsEnvVar) != "" {
+		log.Logger(packageName).Warn(
+			"client certificate authentication requires TLS termination, internal routes are not served",
+			slog.String("env_var", ClientCertRootsEnvVar),
+		)
+	}
+
+	// Handle template assets
+	tmplFS := web.GetTmplFS()
+	renderer, _ := render.New(tmplFS, web.TemplateBaseDirName)
+
 	// Create groups (sub-groups are also possible)
 	r.Group(func(r *router.Router) {
 		// Secure frontend with security headers
@@ -163,13 +347,10 @@ func main() {
 		// Secure frontend with CORF checks (you can customize the middleware as needed)
 		r.Use(http.NewCrossOriginProtection().Handler)
 
-		// Handle template assets
-		tmplFS := web.GetTmplFS()
-		renderer, _ := render.New(tmplFS, web.TemplateBaseDirName)
 		r.Handle(
 			otel.WrapHandler(
 				"GET /",
-				NewExampleTemplateRender(renderer, exec),
+				NewExampleTemplateRender(renderer),
 			),
 		)
 	})
@@ -182,7 +363,118 @@ func main() {
 		),
 	)
 
-	server.Run(otel.WrapMux(r, packageName), conf)
+	// Streaming routes are served by a dedicated router, as timeout and compression middlewares buffer
+	// responses. Protect them with a context deadline instead, which handlers must honor
+	sr := router.New()
+	sr.Use(NewAccessLogMiddleware(sr, accessLogConf))
+	sr.Use(NewContextTimeoutMiddleware(30 * time.Second))
+	sr.Use(maxbytes.NewMiddleware(100000))
+	sr.Use(decompressMiddleware)
+
+	// Record backpressure metrics, revealing slow consumers
+	streamMetricsMiddleware, err := NewStreamMetricsMiddleware()
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	sr.Use(streamMetricsMiddleware)
+
+	// Streaming handlers report mid-stream failures through a protocol-level error frame
+	sr.Handle(
+		otel.WrapHandler(
+			"GET /stream/ndjson",
+			NewExampleNDJSONHandler(exampleStreamProducer),
+		),
+	)
+
+	sr.Handle(
+		otel.WrapHandler(
+			"GET /stream/sse",
+			NewExampleSSEHandler(exampleStreamProducer),
+		),
+	)
+
+	sr.Group(func(r *router.Router) {
+		r.Use(sechead.NewMiddleware(sechead.SecHeadersDefaultStrict))
+		r.Use(http.NewCrossOriginProtection().Handler)
+
+		// Render page shell immediately, streaming slow fragments as they resolve
+		r.Handle(
+			otel.WrapHandler(
+				"GET /stream/skeleton",
+				NewExampleSkeletonHandler(renderer, exampleSkeletonFragments()),
+			),
+		)
+	})
+
+	// Upload routes are served by a dedicated router as well, allowing larger and longer requests. Clients
+	// send uploads in chunks of at most body limit, and resume interrupted ones from their offset. Data is
+	// stored on disk (use a volume shared across instances), cache only holds upload state
+	uploadConf := UploadConfig{
+		Dir:     filepath.Join(os.TempDir(), "uploads"),
+		MaxSize: 1 << 30,
+		TTL:     24 * time.Hour,
+	}
+
+	err = os.MkdirAll(uploadConf.Dir, 0o700)
+	if err != nil {
+		flog.FallbackError(fmt.Errorf("error creating upload directory: %w", err))
+		os.Exit(1)
+	}
+
+	stopStaleUploads := WatchStaleUploads(uploadConf, time.Hour)
+	defer stopStaleUploads()
+
+	ur := router.New()
+	ur.Use(NewAccessLogMiddleware(ur, accessLogConf))
+	ur.Use(NewContextTimeoutMiddleware(5 * time.Minute))
+	ur.Use(maxbytes.NewMiddleware(8 << 20))
+
+	ur.Handle(
+		otel.WrapHandler(
+			"POST /uploads/{$}",
+			NewExampleUploadCreateHandler(cacheClient, uploadConf),
+		),
+	)
+
+	ur.Handle(
+		otel.WrapHandler(
+			"HEAD /uploads/{id}",
+			NewExampleUploadOffsetHandler(cacheClient),
+		),
+	)
+
+	ur.Handle(
+		otel.WrapHandler(
+			"PATCH /uploads/{id}",
+			NewExampleUploadAppendHandler(cacheClient, uploadConf),
+		),
+	)
+
+	root := router.New()
+	root.Handle("/stream/", sr)
+	root.Handle("/uploads/", ur)
+	root.Handle("/", r)
+
+	// Signal readiness through a file as well, for environments not relying on HTTP probes. File only exists
+	// while server accepts connections
+	readinessFile := NewReadinessFile(os.Getenv(ReadinessFileEnvVar))
+
+	stopReadinessFile := readinessFile.Watch(
+		ReadinessDialAddr(conf.Server.BindAddr, conf.Server.BindPort),
+		5*time.Second,
+	)
+
+	server.Run(otel.WrapMux(root, packageName), conf)
+
+	// Server may have stopped without a shutdown signal
+	stopReadinessFile()
+
+	err = readinessFile.MarkNotReady()
+	if err != nil {
+		flog.FallbackError(err)
+	}
 }
 
 func NewExampleHandler(exec failsafe.Executor[any]) http.HandlerFunc {
@@ -231,19 +523,26 @@ func NewExampleHandler(exec failsafe.Executor[any]) http.HandlerFunc {
 	}
 }
 
-func NewExampleTemplateRender(tr *render.TemplateRenderer, exec failsafe.Executor[any]) http.HandlerFunc {
+// NewExampleTemplateRender renders the template matching request path. Rendering is not retried, as
+// template execution is deterministic and would fail again.
+func NewExampleTemplateRender(tr *render.TemplateRenderer) http.HandlerFunc {
+	return newTemplateRenderHandler(tr, map[string]any{
+		"WorldName": "WoRlD",
+	})
+}
+
+// newTemplateRenderHandler renders the template matching request path with data.
+func newTemplateRenderHandler(tr *render.TemplateRenderer, data any) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := exec.Run(func() error {
-			return tr.Execute(
-				w,
-				// Mind about file extension
-				r.URL.Path+".gotmpl.html",
-				map[string]any{
-					"WorldName": "WoRlD",
-				},
-				headval.MIMETextHTMLCharsetUTF8,
-			)
-		})
+		// Buffer rendering so that execution errors don't leave a partial body behind
+		err := ExecuteBuffered(
+			tr,
+			w,
+			// Mind about file extension
+			r.URL.Path+".gotmpl.html",
+			data,
+			headval.MIMETextHTMLCharsetUTF8,
+		)
 		if err != nil {
 			if errors.Is(err, render.ErrTemplateNotFound) {
 				http.NotFound(w, r)
@@ -0,0 +1,136 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+)
+
+// QueryRowLimitDefault is the default maximum number of rows returned by list / query handlers.
+const QueryRowLimitDefault = 1000
+
+var ErrInvalidQueryLimit = errors.New("query limit must be positive")
+
+// Querier runs queries, e.g. [pgxpool.Pool].
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// QueryCapped runs query, returning at most limit rows scanned with scan. A `LIMIT` clause is appended
+// to query, which thus must be a single statement without a `LIMIT` clause of its own. Trailing semicolons
+// are removed, and the clause is appended on its own line so that it is not swallowed by a trailing line
+// comment. When more rows are available, truncated is true.
+func QueryCapped[T any](
+	ctx context.Context,
+	client Querier,
+	limit int,
+	scan pgx.RowToFunc[T],
+	query string,
+	args ...any,
+) (result []T, truncated bool, err error) {
+	if limit <= 0 {
+		return nil, false, fmt.Errorf("%d: %w", limit, ErrInvalidQueryLimit)
+	}
+
+	query = strings.TrimRight(query, "; \t\r\n")
+
+	// Fetch one more row than needed to detect truncation
+	rows, err := client.Query(
+		ctx,
+		query+"\nLIMIT $"+strconv.Itoa(len(args)+1),
+		append(args[:len(args):len(args)], limit+1)...,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("error running capped query: %w", err)
+	}
+	defer rows.Close()
+
+	result = make([]T, 0, min(limit, QueryRowLimitDefault))
+
+	for rows.Next() {
+		// Extra row denotes truncation
+		if len(result) >= limit {
+			truncated = true
+
+			break
+		}
+
+		item, err := scan(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("error scanning capped query row: %w", err)
+		}
+
+		result = append(result, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, false, fmt.Errorf("error iterating capped query rows: %w", err)
+	}
+
+	return result, truncated, nil
+}
+
+// NewExampleDatabaseListHandler lists tasks, returning at most limit of them.
+func NewExampleDatabaseListHandler(client *pgxpool.Pool, exec failsafe.Executor[any], limit int) http.HandlerFunc {
+	type Task struct {
+		ID        int       `db:"id"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			tasks     []Task
+			truncated bool
+		)
+
+		err := exec.Run(func() error {
+			var err error
+
+			tasks, truncated, err = QueryCapped(
+				r.Context(),
+				client,
+				limit,
+				pgx.RowToStructByName[Task],
+				`SELECT id, created_at FROM tasks ORDER BY id`,
+			)
+
+			return err
+		})
+		if err != nil {
+			log.ErrLog(packageName, "error database list", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		type ExampleOutput struct {
+			Tasks     []Task
+			Truncated bool
+		}
+
+		resp.JSON(w, ExampleOutput{
+			Tasks:     tasks,
+			Truncated: truncated,
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestQueryCapped(t *testing.T) {
+	t.Parallel()
+
+	scanInt := func(row pgx.CollectableRow) (int, error) {
+		var v int
+
+		err := row.Scan(&v)
+
+		return v, err
+	}
+
+	tests := []struct {
+		Name              string
+		Available         int
+		Limit             int
+		Query             string
+		ExpectedQuery     string
+		ExpectedRows      int
+		ExpectedTruncated bool
+	}{
+		{
+			Name:              "truncated",
+			Available:         5,
+			Limit:             3,
+			Query:             `SELECT id FROM tasks WHERE owner = $1`,
+			ExpectedQuery:     "SELECT id FROM tasks WHERE owner = $1\nLIMIT $2",
+			ExpectedRows:      3,
+			ExpectedTruncated: true,
+		},
+		{
+			Name:              "exactly at limit",
+			Available:         3,
+			Limit:             3,
+			Query:             `SELECT id FROM tasks WHERE owner = $1`,
+			ExpectedQuery:     "SELECT id FROM tasks WHERE owner = $1\nLIMIT $2",
+			ExpectedRows:      3,
+			ExpectedTruncated: false,
+		},
+		{
+			Name:              "trailing semicolon",
+			Available:         1,
+			Limit:             3,
+			Query:             "SELECT id FROM tasks WHERE owner = $1;\n",
+			ExpectedQuery:     "SELECT id FROM tasks WHERE owner = $1\nLIMIT $2",
+			ExpectedRows:      1,
+			ExpectedTruncated: false,
+		},
+		{
+			Name:              "trailing line comment",
+			Available:         1,
+			Limit:             3,
+			Query:             "SELECT id FROM tasks WHERE owner = $1 -- by owner",
+			ExpectedQuery:     "SELECT id FROM tasks WHERE owner = $1 -- by owner\nLIMIT $2",
+			ExpectedRows:      1,
+			ExpectedTruncated: false,
+		},
+	}
+
+	for _, test := range tests {
+		db := &fakeDB{
+			// Honor LIMIT argument, as the database would
+			rows: func(_ string, args []any) ([][]any, error) {
+				limit, _ := args[len(args)-1].(int)
+
+				rows := make([][]any, 0, test.Available)
+				for i := range min(test.Available, limit) {
+					rows = append(rows, []any{i})
+				}
+
+				return rows, nil
+			},
+		}
+
+		result, truncated, err := QueryCapped(context.Background(), db, test.Limit, scanInt, test.Query, "me")
+		if err != nil {
+			t.Fatalf("%s: QueryCapped: %s", test.Name, err)
+		}
+
+		if got := db.statements[0].SQL; got != test.ExpectedQuery {
+			t.Errorf("%s: expected query %q; got %q", test.Name, test.ExpectedQuery, got)
+		}
+
+		// One more row is fetched than needed to detect truncation
+		if got := db.statements[0].Args; !slices.Equal(got, []any{"me", test.Limit + 1}) {
+			t.Errorf("%s: expected args %v; got %v", test.Name, []any{"me", test.Limit + 1}, got)
+		}
+
+		if len(result) != test.ExpectedRows {
+			t.Errorf("%s: expected %d rows; got %d", test.Name, test.ExpectedRows, len(result))
+		}
+
+		if truncated != test.ExpectedTruncated {
+			t.Errorf("%s: expected truncated %t; got %t", test.Name, test.ExpectedTruncated, truncated)
+		}
+	}
+}
+
+func TestQueryCappedInvalidLimit(t *testing.T) {
+	t.Parallel()
+
+	for _, limit := range []int{0, -1} {
+		db := &fakeDB{}
+
+		_, _, err := QueryCapped(
+			context.Background(),
+			db,
+			limit,
+			pgx.RowTo[int],
+			`SELECT id FROM tasks`,
+		)
+		if !errors.Is(err, ErrInvalidQueryLimit) {
+			t.Errorf("%d: expected %s; got %v", limit, ErrInvalidQueryLimit, err)
+		}
+
+		if len(db.SQL()) != 0 {
+			t.Errorf("%d: expected no query; got %q", limit, db.SQL())
+		}
+	}
+}
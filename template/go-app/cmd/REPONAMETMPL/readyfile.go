@@ -0,0 +1,169 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/config"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+)
+
+// ReadinessFileEnvVar is the environment variable holding the readiness file path. Readiness file
+// is disabled when it is unset or empty.
+var ReadinessFileEnvVar = strings.ToUpper(config.ConfigurationEnvVarPrefix) + "_SERVER_READINESS_FILE_PATH"
+
+// ReadinessFile signals readiness through the existence of a file, for environments that do not rely
+// on HTTP probes. It complements [monitoring.ReadinessHandler].
+type ReadinessFile struct {
+	path string
+}
+
+// NewReadinessFile returns a readiness file located at path. Empty path disables it, making all
+// methods no-ops.
+func NewReadinessFile(path string) *ReadinessFile {
+	return &ReadinessFile{path: path}
+}
+
+// MarkReady creates the readiness file, updating its modification time if it already exists.
+func (f *ReadinessFile) MarkReady() error {
+	if f.path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating readiness file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("error closing readiness file: %w", err)
+	}
+
+	now := time.Now()
+
+	err = os.Chtimes(f.path, now, now)
+	if err != nil {
+		return fmt.Errorf("error touching readiness file: %w", err)
+	}
+
+	return nil
+}
+
+// MarkNotReady removes the readiness file. Removing a missing file is not an error.
+func (f *ReadinessFile) MarkNotReady() error {
+	if f.path == "" {
+		return nil
+	}
+
+	err := os.Remove(f.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing readiness file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadinessDialAddr returns the address to dial to check that a server bound to bindAddr and port accepts
+// connections. bindAddr may be enclosed in brackets, as IPv6 addresses of [config.Server.BindAddr], and
+// unspecified addresses (e.g. default `[::]`) are dialed through loopback.
+func ReadinessDialAddr(bindAddr string, port int) string {
+	host := strings.TrimSuffix(strings.TrimPrefix(bindAddr, "["), "]")
+
+	ip := net.ParseIP(host)
+	if host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// Watch maintains the readiness file of a server listening on addr, until a shutdown signal is received
+// or returned function is called. Stale file from a previous run is removed, then file is created once addr
+// accepts connections, and refreshed every interval while it does. File is removed as soon as shutdown
+// begins, that is, when a shutdown signal is received rather than once shutdown is complete, or when addr
+// stops accepting connections.
+// As process may still exit without removing the file (e.g. [server.Run] exits on failure, or on crash),
+// consumers should consider a file that was not refreshed for a few intervals as stale.
+func (f *ReadinessFile) Watch(addr string, interval time.Duration) func() {
+	if f.path == "" {
+		return func() {}
+	}
+
+	err := f.MarkNotReady()
+	if err != nil {
+		log.ErrLog(packageName, "error removing stale readiness file", err)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	// Same signals as [server.Run], all registered channels are notified
+	signal.Notify(
+		sigChan,
+		os.Interrupt,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+	)
+
+	go func() {
+		defer close(stopped)
+
+		// Poll frequently until server is up
+		delay := min(interval, 100*time.Millisecond)
+
+		for {
+			select {
+			case <-sigChan:
+				err := f.MarkNotReady()
+				if err != nil {
+					log.ErrLog(packageName, "error removing readiness file", err)
+				}
+
+				return
+			case <-done:
+				return
+			case <-time.After(delay):
+			}
+
+			conn, err := net.DialTimeout("tcp", addr, interval)
+			if err != nil {
+				err = f.MarkNotReady()
+				if err != nil {
+					log.ErrLog(packageName, "error removing readiness file", err)
+				}
+
+				continue
+			}
+
+			_ = conn.Close()
+			delay = interval
+
+			err = f.MarkReady()
+			if err != nil {
+				log.ErrLog(packageName, "error creating readiness file", err)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+		<-stopped
+	}
+}
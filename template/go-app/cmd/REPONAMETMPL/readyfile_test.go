@@ -0,0 +1,191 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitFile waits for file at path to exist or not, returning whether it did in time.
+func waitFile(path string, exists bool) bool {
+	deadline := time.Now().Add(2 * time.Second)
+
+	for time.Now().Before(deadline) {
+		_, err := os.Stat(path)
+		if exists == (err == nil) {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestReadinessFileWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+
+	// Stale file from a previous run
+	err := os.WriteFile(path, nil, 0o644)
+	if err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+
+	addr := lis.Addr().String()
+
+	// Server not listening yet
+	err = lis.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	f := NewReadinessFile(path)
+
+	stop := f.Watch(addr, 20*time.Millisecond)
+	defer stop()
+
+	if !waitFile(path, false) {
+		t.Fatalf("expected stale readiness file to be removed")
+	}
+
+	// Not listening, file must not be created
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = os.Stat(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected no readiness file before listening; got %v", err)
+	}
+
+	lis, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+
+	if !waitFile(path, true) {
+		t.Errorf("expected readiness file once listening")
+	}
+
+	// Server stopped without shutdown signal
+	err = lis.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	if !waitFile(path, false) {
+		t.Errorf("expected readiness file to be removed once not listening")
+	}
+}
+
+func TestReadinessFileWatchDefaultBindAddr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+
+	// Default of [config.Server.BindAddr], listened on the way [server.Run] does
+	const bindAddr = "[::]"
+
+	lis, err := net.Listen("tcp", bindAddr+":0")
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+	defer lis.Close()
+
+	f := NewReadinessFile(path)
+
+	stop := f.Watch(ReadinessDialAddr(bindAddr, lis.Addr().(*net.TCPAddr).Port), 20*time.Millisecond)
+	defer stop()
+
+	if !waitFile(path, true) {
+		t.Errorf("expected readiness file once listening")
+	}
+}
+
+func TestReadinessDialAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		BindAddr     string
+		ExpectedAddr string
+	}{
+		{BindAddr: "[::]", ExpectedAddr: "localhost:8080"},
+		{BindAddr: "::", ExpectedAddr: "localhost:8080"},
+		{BindAddr: "0.0.0.0", ExpectedAddr: "localhost:8080"},
+		{BindAddr: "", ExpectedAddr: "localhost:8080"},
+		{BindAddr: "[::1]", ExpectedAddr: "[::1]:8080"},
+		{BindAddr: "127.0.0.1", ExpectedAddr: "127.0.0.1:8080"},
+		{BindAddr: "app.internal", ExpectedAddr: "app.internal:8080"},
+	}
+
+	for _, test := range tests {
+		addr := ReadinessDialAddr(test.BindAddr, 8080)
+		if addr != test.ExpectedAddr {
+			t.Errorf("%q: expected %s; got %s", test.BindAddr, test.ExpectedAddr, addr)
+		}
+
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil || port != "8080" {
+			t.Errorf("%q: expected dialable address; got %s (%v)", test.BindAddr, addr, err)
+		}
+	}
+}
+
+func TestReadinessFileWatchShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+	defer lis.Close()
+
+	f := NewReadinessFile(path)
+
+	stop := f.Watch(lis.Addr().String(), 20*time.Millisecond)
+	defer stop()
+
+	if !waitFile(path, true) {
+		t.Fatalf("expected readiness file once listening")
+	}
+
+	// Signal is caught by the watcher, hence does not terminate the test
+	err = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	if err != nil {
+		t.Fatalf("Kill: %s", err)
+	}
+
+	// Removed while still listening, that is, as soon as shutdown begins
+	if !waitFile(path, false) {
+		t.Errorf("expected readiness file to be removed on shutdown signal")
+	}
+}
+
+func TestReadinessFileDisabled(t *testing.T) {
+	t.Parallel()
+
+	f := NewReadinessFile("")
+
+	err := f.MarkReady()
+	if err != nil {
+		t.Errorf("MarkReady: %s", err)
+	}
+
+	err = f.MarkNotReady()
+	if err != nil {
+		t.Errorf("MarkNotReady: %s", err)
+	}
+
+	f.Watch("127.0.0.1:0", time.Second)()
+}
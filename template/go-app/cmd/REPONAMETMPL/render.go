@@ -0,0 +1,82 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/convenience/render"
+)
+
+var ErrTemplatePanic = errors.New("panic during template execution")
+
+// bufferedResponseWriter captures a response in memory so that it can be discarded if it
+// turns out to be incomplete.
+type bufferedResponseWriter struct {
+	header     http.Header
+	buffer     bytes.Buffer
+	statusCode int
+}
+
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return bw.buffer.Write(b)
+}
+
+func (bw *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if bw.statusCode == 0 {
+		bw.statusCode = statusCode
+	}
+}
+
+// ExecuteBuffered executes a template like [render.TemplateRenderer.Execute], but only writes to w once
+// execution succeeded. On error or panic, nothing is written to w so that caller can send a clean
+// error response instead of a partial body.
+func ExecuteBuffered(
+	tr *render.TemplateRenderer,
+	w http.ResponseWriter,
+	templateName string,
+	data any,
+	contentType string,
+) (err error) {
+	bw := &bufferedResponseWriter{
+		header: make(http.Header),
+	}
+
+	func() {
+		defer func() {
+			rec := recover()
+			if rec != nil {
+				err = fmt.Errorf("%s: %w: %v", templateName, ErrTemplatePanic, rec)
+			}
+		}()
+
+		err = tr.Execute(bw, templateName, data, contentType)
+	}()
+	if err != nil {
+		return err
+	}
+
+	maps.Copy(w.Header(), bw.header)
+
+	if bw.statusCode != 0 {
+		w.WriteHeader(bw.statusCode)
+	}
+
+	_, err = bw.buffer.WriteTo(w)
+	if err != nil {
+		return fmt.Errorf("error writing template %s: %w", templateName, err)
+	}
+
+	return nil
+}
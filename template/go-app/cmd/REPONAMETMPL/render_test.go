@@ -0,0 +1,137 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"embed"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/render"
+)
+
+//go:embed testdata/tmpl
+var testTmplFS embed.FS
+
+func newTestRenderer(t *testing.T) *render.TemplateRenderer {
+	t.Helper()
+
+	tr, err := render.New(testTmplFS, "testdata/tmpl")
+	if err != nil {
+		t.Fatalf("render.New: %s", err)
+	}
+
+	return tr
+}
+
+// panickingItems yields an item, then panics. Unlike panics of functions and methods called by templates,
+// panics of range iterators are not recovered by template execution.
+func panickingItems(yield func(string) bool) {
+	if !yield("partial") {
+		return
+	}
+
+	panic("iterator failure")
+}
+
+func TestExampleTemplateRender(t *testing.T) {
+	t.Parallel()
+
+	handler := NewExampleTemplateRender(newTestRenderer(t))
+
+	tests := []struct {
+		RequestPath    string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			RequestPath:    "/ok",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "<p>WoRlD</p>\n",
+		},
+		{
+			RequestPath:    "/broken",
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedBody:   http.StatusText(http.StatusInternalServerError) + "\n",
+		},
+		{
+			RequestPath:    "/missing",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   "404 page not found\n",
+		},
+	}
+
+	for _, test := range tests {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, test.RequestPath, nil))
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.RequestPath, test.ExpectedStatus, rr.Code)
+		}
+
+		if rr.Body.String() != test.ExpectedBody {
+			t.Errorf("%s: expected body %q; got %q", test.RequestPath, test.ExpectedBody, rr.Body.String())
+		}
+	}
+}
+
+func TestExecuteBufferedPanic(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestRenderer(t)
+
+	rr := httptest.NewRecorder()
+
+	err := ExecuteBuffered(
+		tr,
+		rr,
+		"panic.gotmpl.html",
+		map[string]any{"Items": iter.Seq[string](panickingItems)},
+		headval.MIMETextHTMLCharsetUTF8,
+	)
+	if !errors.Is(err, ErrTemplatePanic) {
+		t.Fatalf("expected %s; got %v", ErrTemplatePanic, err)
+	}
+
+	if rr.Body.Len() != 0 {
+		t.Errorf("expected no body; got %q", rr.Body.String())
+	}
+
+	if rr.Header().Get(headkey.ContentType) != "" {
+		t.Errorf("expected no headers; got %v", rr.Header())
+	}
+}
+
+func TestTemplateRenderHandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	handler := newTemplateRenderHandler(
+		newTestRenderer(t),
+		map[string]any{"Items": iter.Seq[string](panickingItems)},
+	)
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d; got %d", http.StatusInternalServerError, rr.Code)
+	}
+
+	expectedBody := http.StatusText(http.StatusInternalServerError) + "\n"
+	if rr.Body.String() != expectedBody {
+		t.Errorf("expected body %q; got %q", expectedBody, rr.Body.String())
+	}
+
+	if strings.HasPrefix(rr.Header().Get(headkey.ContentType), "text/html") {
+		t.Errorf("expected no html content type; got %s", rr.Header().Get(headkey.ContentType))
+	}
+}
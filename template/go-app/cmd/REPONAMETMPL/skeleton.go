@@ -0,0 +1,167 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/render"
+)
+
+const (
+	skeletonTemplateName         = "skeleton.gotmpl.html"
+	skeletonFragmentTemplateName = "skeleton-fragment.gotmpl.html"
+	skeletonEndTemplateName      = "skeleton-end.gotmpl.html"
+)
+
+// SkeletonFragment is a page fragment whose data is slow to resolve.
+type SkeletonFragment struct {
+	// Slot is the name of the placeholder slot the fragment fills
+	Slot string
+	// Resolve returns fragment content
+	Resolve func(ctx context.Context) (any, error)
+}
+
+type skeletonFragmentResult struct {
+	slot    string
+	content any
+	err     error
+}
+
+// NewExampleSkeletonHandler renders the page shell immediately, with a placeholder slot for each fragment,
+// then streams fragments as they resolve, in completion order. Slots are filled by the browser using
+// declarative shadow DOM, thus without any script.
+// Note that as headers are sent before fragments resolve, errors can't be reported with status codes,
+// failing fragments are rendered as unavailable instead.
+func NewExampleSkeletonHandler(tr *render.TemplateRenderer, fragments []SkeletonFragment) http.HandlerFunc {
+	slots := make([]string, 0, len(fragments))
+	for _, fragment := range fragments {
+		slots = append(slots, fragment.Slot)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+
+		// Buffer shell only, so that a failure still yields a clean error response
+		err := ExecuteBuffered(
+			tr,
+			w,
+			skeletonTemplateName,
+			map[string]any{
+				"WorldName": "WoRlD",
+				"Slots":     slots,
+			},
+			headval.MIMETextHTMLCharsetUTF8,
+		)
+		if err != nil {
+			log.ErrLog(packageName, "error rendering skeleton", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		err = flush(rc)
+		if err != nil {
+			log.ErrLog(packageName, "error flushing skeleton", err)
+
+			return
+		}
+
+		results := make(chan skeletonFragmentResult, len(fragments))
+
+		for _, fragment := range fragments {
+			go func() {
+				content, err := fragment.Resolve(r.Context())
+				results <- skeletonFragmentResult{
+					slot:    fragment.Slot,
+					content: content,
+					err:     err,
+				}
+			}()
+		}
+
+		for range fragments {
+			var result skeletonFragmentResult
+
+			select {
+			case <-r.Context().Done():
+				return
+			case result = <-results:
+			}
+
+			if result.err != nil {
+				log.ErrLog(packageName, "error resolving skeleton fragment", result.err)
+
+				result.content = "Unavailable"
+			}
+
+			err := tr.Execute(
+				w,
+				skeletonFragmentTemplateName,
+				map[string]any{
+					"Slot":    result.slot,
+					"Content": result.content,
+				},
+				headval.MIMETextHTMLCharsetUTF8,
+			)
+			if err != nil {
+				log.ErrLog(packageName, "error rendering skeleton fragment", err)
+
+				return
+			}
+
+			err = flush(rc)
+			if err != nil {
+				log.ErrLog(packageName, "error flushing skeleton fragment", err)
+
+				return
+			}
+		}
+
+		err = tr.Execute(w, skeletonEndTemplateName, nil, headval.MIMETextHTMLCharsetUTF8)
+		if err != nil {
+			log.ErrLog(packageName, "error rendering skeleton end", err)
+		}
+	}
+}
+
+// exampleSkeletonFragments returns fragments simulating slow data sources.
+func exampleSkeletonFragments() []SkeletonFragment {
+	delayed := func(delay time.Duration, content func() any) func(ctx context.Context) (any, error) {
+		return func(ctx context.Context) (any, error) {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("error resolving fragment: %w", ctx.Err())
+			case <-time.After(delay):
+				return content(), nil
+			}
+		}
+	}
+
+	return []SkeletonFragment{
+		{
+			Slot: "time",
+			Resolve: delayed(time.Second, func() any {
+				return time.Now().Format(time.RFC1123)
+			}),
+		},
+		{
+			Slot: "greeting",
+			Resolve: delayed(300*time.Millisecond, func() any {
+				return "Welcome back!"
+			}),
+		},
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemadev/REPONAMETMPL/web"
+	"github.com/kemadev/go-framework/pkg/convenience/render"
+)
+
+// readUntil reads lines from r until one contains marker, returning them.
+func readUntil(r *bufio.Reader, marker string) (string, error) {
+	var b strings.Builder
+
+	for {
+		line, err := r.ReadString('\n')
+		b.WriteString(line)
+
+		if strings.Contains(line, marker) {
+			return b.String(), nil
+		}
+
+		if err != nil {
+			return b.String(), err
+		}
+	}
+}
+
+func TestExampleSkeletonHandler(t *testing.T) {
+	t.Parallel()
+
+	tr, err := render.New(web.GetTmplFS(), web.TemplateBaseDirName)
+	if err != nil {
+		t.Fatalf("render.New: %s", err)
+	}
+
+	releaseSlow := make(chan struct{})
+	releaseFailing := make(chan struct{})
+
+	fragments := []SkeletonFragment{
+		{
+			Slot: "slow",
+			Resolve: func(_ context.Context) (any, error) {
+				<-releaseSlow
+
+				return "Slow data", nil
+			},
+		},
+		{
+			Slot: "failing",
+			Resolve: func(_ context.Context) (any, error) {
+				<-releaseFailing
+
+				return nil, errors.New("unavailable data source")
+			},
+		},
+	}
+
+	server := httptest.NewServer(NewExampleSkeletonHandler(tr, fragments))
+	defer server.Close()
+
+	// Fail rather than hang should shell be held until fragments resolve
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	defer res.Body.Close()
+
+	body := bufio.NewReader(res.Body)
+
+	// Shell is received while fragments are still resolving
+	shell, err := readUntil(body, "</template>")
+	if err != nil {
+		t.Fatalf("expected shell before fragments resolve, got %q: %s", shell, err)
+	}
+
+	for _, slot := range []string{"slow", "failing"} {
+		if !strings.Contains(shell, `<slot name="`+slot+`">Loading...</slot>`) {
+			t.Errorf("expected placeholder for slot %q; got %q", slot, shell)
+		}
+	}
+
+	// Fragments are streamed in completion order
+	close(releaseFailing)
+
+	failing, err := readUntil(body, `slot="failing"`)
+	if err != nil {
+		t.Fatalf("expected failing fragment, got %q: %s", failing, err)
+	}
+
+	if !strings.Contains(failing, `<div slot="failing">Unavailable</div>`) {
+		t.Errorf("expected failing fragment to be unavailable; got %q", failing)
+	}
+
+	close(releaseSlow)
+
+	rest, err := readUntil(body, "</body>")
+	if err != nil {
+		t.Fatalf("expected page end, got %q: %s", rest, err)
+	}
+
+	if !strings.Contains(rest, `<div slot="slow">Slow data</div>`) {
+		t.Errorf("expected slow fragment; got %q", rest)
+	}
+
+	if !strings.HasSuffix(strings.TrimSpace(rest), "</main>\n</body>") {
+		t.Errorf("expected page to end with closing elements; got %q", rest)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+)
+
+const (
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	MIMETextEventStream   = "text/event-stream"
+)
+
+// StreamProducer produces items to stream, calling emit for each of them. Returning an error aborts
+// the stream, which is then terminated by an error frame, as status code can't be changed once
+// headers are sent.
+type StreamProducer func(ctx context.Context, emit func(item any) error) error
+
+// streamErrorFrame is the last frame sent when the stream is aborted by its producer.
+type streamErrorFrame struct {
+	Error string `json:"error"`
+}
+
+// NewExampleNDJSONHandler streams items from produce as newline-delimited JSON. Should produce fail,
+// a final object with an `error` field is sent so that clients can detect the failure.
+func NewExampleNDJSONHandler(produce StreamProducer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		enc := json.NewEncoder(w)
+
+		w.Header().Set(headkey.ContentType, MIMEApplicationNDJSON)
+		w.WriteHeader(http.StatusOK)
+
+		err := produce(r.Context(), func(item any) error {
+			err := enc.Encode(item)
+			if err != nil {
+				return fmt.Errorf("error encoding stream item: %w", err)
+			}
+
+			return flush(rc)
+		})
+		if err != nil {
+			log.ErrLog(packageName, "error producing ndjson stream", err)
+
+			// Do not leak internal error details to clients
+			_ = enc.Encode(streamErrorFrame{
+				Error: http.StatusText(http.StatusInternalServerError),
+			})
+			_ = flush(rc)
+		}
+	}
+}
+
+// NewExampleSSEHandler streams items from produce as server-sent events. Should produce fail,
+// a final `error` event is sent so that clients can detect the failure.
+func NewExampleSSEHandler(produce StreamProducer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+
+		w.Header().Set(headkey.ContentType, MIMETextEventStream)
+		w.Header().Set(headkey.CacheControl, "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		err := produce(r.Context(), func(item any) error {
+			err := writeSSEEvent(w, "", item)
+			if err != nil {
+				return err
+			}
+
+			return flush(rc)
+		})
+		if err != nil {
+			log.ErrLog(packageName, "error producing sse stream", err)
+
+			// Do not leak internal error details to clients
+			_ = writeSSEEvent(w, "error", streamErrorFrame{
+				Error: http.StatusText(http.StatusInternalServerError),
+			})
+			_ = flush(rc)
+		}
+	}
+}
+
+// writeSSEEvent writes data as a JSON-encoded server-sent event. Empty event denotes the default
+// `message` event type.
+func writeSSEEvent(w http.ResponseWriter, event string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error encoding stream item: %w", err)
+	}
+
+	if event != "" {
+		_, err = fmt.Fprintf(w, "event: %s\n", event)
+		if err != nil {
+			return fmt.Errorf("error writing stream event: %w", err)
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "data: %s\n\n", body)
+	if err != nil {
+		return fmt.Errorf("error writing stream event: %w", err)
+	}
+
+	return nil
+}
+
+// NewContextTimeoutMiddleware returns a middleware that sets a deadline of t on request context. Unlike
+// [timeout.NewMiddleware], response is not buffered, making it suitable for streaming handlers, which
+// must honor their context. Connection write deadline is set to the same deadline, so that responses
+// outliving server write timeout are not cut.
+func NewContextTimeoutMiddleware(t time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), t)
+			defer cancel()
+
+			deadline, _ := ctx.Deadline()
+
+			err := http.NewResponseController(w).SetWriteDeadline(deadline)
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				log.ErrLog(packageName, "error setting write deadline", err)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// flush sends buffered data to the client. Writers that do not support flushing (e.g. when
+// wrapped by [net/http.TimeoutHandler]) are silently ignored, data will be sent once handler returns.
+func flush(rc *http.ResponseController) error {
+	err := rc.Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("error flushing stream: %w", err)
+	}
+
+	return nil
+}
+
+// exampleStreamProducer emits a few items, simulating a slow source.
+func exampleStreamProducer(ctx context.Context, emit func(item any) error) error {
+	type exampleItem struct {
+		Index int
+		At    time.Time
+	}
+
+	for i := range 5 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		err := emit(exampleItem{Index: i, At: time.Now()})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+func TestStreamErrorFrame(t *testing.T) {
+	t.Parallel()
+
+	errProducer := errors.New("producer failed")
+
+	// Emit an item, then fail mid-stream
+	produce := func(_ context.Context, emit func(item any) error) error {
+		err := emit(map[string]int{"Index": 0})
+		if err != nil {
+			return err
+		}
+
+		return errProducer
+	}
+
+	tests := []struct {
+		Name                string
+		Handler             http.HandlerFunc
+		ExpectedContentType string
+		ExpectedBody        string
+	}{
+		{
+			Name:                "ndjson",
+			Handler:             NewExampleNDJSONHandler(produce),
+			ExpectedContentType: MIMEApplicationNDJSON,
+			ExpectedBody:        "{\"Index\":0}\n{\"error\":\"Internal Server Error\"}\n",
+		},
+		{
+			Name:                "sse",
+			Handler:             NewExampleSSEHandler(produce),
+			ExpectedContentType: MIMETextEventStream,
+			ExpectedBody: "data: {\"Index\":0}\n\n" +
+				"event: error\ndata: {\"error\":\"Internal Server Error\"}\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			test.Handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d; got %d", http.StatusOK, rr.Code)
+			}
+
+			if got := rr.Header().Get(headkey.ContentType); got != test.ExpectedContentType {
+				t.Errorf("expected content type %q; got %q", test.ExpectedContentType, got)
+			}
+
+			if got := rr.Body.String(); got != test.ExpectedBody {
+				t.Errorf("expected body %q; got %q", test.ExpectedBody, got)
+			}
+
+			if !rr.Flushed {
+				t.Errorf("expected stream to be flushed")
+			}
+		})
+	}
+}
+
+func TestContextTimeoutMiddlewareNotBuffered(t *testing.T) {
+	t.Parallel()
+
+	// Stream routes must not be wrapped by buffering middlewares, or flushes are silently dropped
+	var flushErr error
+
+	handler := NewContextTimeoutMiddleware(time.Second)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("item\n"))
+			flushErr = http.NewResponseController(w).Flush()
+		}),
+	)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if flushErr != nil {
+		t.Errorf("expected flush to be supported; got %s", flushErr)
+	}
+
+	if !rr.Flushed {
+		t.Errorf("expected response to be flushed")
+	}
+}
+
+func TestContextTimeoutMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var deadline time.Time
+
+	handler := NewContextTimeoutMiddleware(time.Minute)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			deadline, _ = r.Context().Deadline()
+		}),
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if deadline.IsZero() || time.Until(deadline) > time.Minute {
+		t.Errorf("expected deadline within a minute; got %s", deadline)
+	}
+}
+
+func TestContextTimeoutMiddlewareOutlivesWriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	const items = 6
+
+	// Stream items for longer than server write timeout
+	produce := func(ctx context.Context, emit func(item any) error) error {
+		for i := range items {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			err := emit(i)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	srv := httptest.NewUnstartedServer(
+		NewContextTimeoutMiddleware(5 * time.Second)(NewExampleNDJSONHandler(produce)),
+	)
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	client := srv.Client()
+	client.Timeout = 5 * time.Second
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+	defer res.Body.Close()
+
+	lines := 0
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lines++
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		t.Errorf("expected stream to complete; got %s", err)
+	}
+
+	if lines != items {
+		t.Errorf("expected %d items; got %d", items, lines)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// streamMetricsResponseWriter records bytes buffered between flushes, and how long flushes take.
+type streamMetricsResponseWriter struct {
+	http.ResponseWriter
+	r             *http.Request
+	rc            *http.ResponseController
+	buffered      int64
+	bufferedBytes metric.Int64Histogram
+	flushDuration metric.Float64Histogram
+}
+
+func (w *streamMetricsResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.buffered += int64(n)
+
+	return n, err
+}
+
+// FlushError flushes the underlying writer, it is used by [net/http.ResponseController].
+func (w *streamMetricsResponseWriter) FlushError() error {
+	start := time.Now()
+
+	err := w.rc.Flush()
+	if err != nil {
+		// Left unwrapped, as callers check for http.ErrNotSupported
+		return err
+	}
+
+	// Flush blocks while client is slow to read, hence its duration reveals slow consumers
+	w.flushDuration.Record(w.r.Context(), time.Since(start).Seconds())
+	w.bufferedBytes.Record(w.r.Context(), w.buffered)
+	w.buffered = 0
+
+	return nil
+}
+
+// Unwrap returns the underlying [net/http.ResponseWriter].
+func (w *streamMetricsResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// NewStreamMetricsMiddleware returns a middleware recording backpressure metrics of streaming responses, that
+// is, bytes buffered before each flush and flush duration, so that slow consumers can be detected.
+func NewStreamMetricsMiddleware() (func(http.Handler) http.Handler, error) {
+	meter := otel.GetMeterProvider().Meter(packageName)
+
+	bufferedBytes, err := meter.Int64Histogram(
+		"http.server.stream.buffered.size",
+		metric.WithDescription("Size of streaming response data buffered before being flushed"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating stream buffered size metric: %w", err)
+	}
+
+	flushDuration, err := meter.Float64Histogram(
+		"http.server.stream.flush.duration",
+		metric.WithDescription("Duration of streaming response flushes"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating stream flush duration metric: %w", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&streamMetricsResponseWriter{
+				ResponseWriter: w,
+				r:              r,
+				rc:             http.NewResponseController(w),
+				bufferedBytes:  bufferedBytes,
+				flushDuration:  flushDuration,
+			}, r)
+		})
+	}, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/router"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// nonFlushingResponseWriter hides [net/http.Flusher] of the underlying writer.
+type nonFlushingResponseWriter struct {
+	http.ResponseWriter
+}
+
+// histogramCountSum returns count and sum of the data points of histogram name, if recorded.
+func histogramCountSum(t *testing.T, rm metricdata.ResourceMetrics, name string) (uint64, float64) {
+	t.Helper()
+
+	var (
+		count uint64
+		sum   float64
+	)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					count += dp.Count
+					sum += float64(dp.Sum)
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					count += dp.Count
+					sum += dp.Sum
+				}
+			default:
+				t.Fatalf("%s: unexpected data type %T", name, m.Data)
+			}
+		}
+	}
+
+	return count, sum
+}
+
+// Not parallel, as it sets global meter provider.
+func TestStreamMetricsMiddleware(t *testing.T) {
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() {
+		otel.SetMeterProvider(previous)
+	})
+
+	chunks := []string{"first\n", "second\n", "third\n"}
+
+	tests := []struct {
+		Name          string
+		Flushable     bool
+		ExpectedError error
+		// Expected number of recorded flushes, and expected bytes flushed overall
+		ExpectedFlushes uint64
+		ExpectedBytes   float64
+	}{
+		{
+			Name:            "flushable",
+			Flushable:       true,
+			ExpectedFlushes: uint64(len(chunks)),
+			ExpectedBytes:   float64(len(strings.Join(chunks, ""))),
+		},
+		{
+			Name:          "not flushable",
+			ExpectedError: http.ErrNotSupported,
+		},
+	}
+
+	for _, test := range tests {
+		reader := sdkmetric.NewManualReader()
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+		streamMetricsMiddleware, err := NewStreamMetricsMiddleware()
+		if err != nil {
+			t.Fatalf("%s: NewStreamMetricsMiddleware: %s", test.Name, err)
+		}
+
+		var flushErr error
+
+		// Same chain as streaming router, access log writer being wrapped by stream metrics one
+		sr := router.New()
+		sr.Use(NewAccessLogMiddleware(sr, AccessLogConfig{Level: slog.LevelError}))
+		sr.Use(streamMetricsMiddleware)
+		sr.HandleFunc("GET /stream/ndjson", func(w http.ResponseWriter, _ *http.Request) {
+			rc := http.NewResponseController(w)
+
+			for _, chunk := range chunks {
+				_, _ = w.Write([]byte(chunk))
+
+				err := rc.Flush()
+				if err != nil {
+					flushErr = err
+				}
+			}
+		})
+
+		rr := httptest.NewRecorder()
+
+		var w http.ResponseWriter = rr
+		if !test.Flushable {
+			w = nonFlushingResponseWriter{ResponseWriter: rr}
+		}
+
+		sr.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream/ndjson", nil))
+
+		if !errors.Is(flushErr, test.ExpectedError) {
+			t.Errorf("%s: expected flush error %v; got %v", test.Name, test.ExpectedError, flushErr)
+		}
+
+		if rr.Flushed != test.Flushable {
+			t.Errorf("%s: expected flushed %t; got %t", test.Name, test.Flushable, rr.Flushed)
+		}
+
+		var rm metricdata.ResourceMetrics
+
+		err = reader.Collect(context.Background(), &rm)
+		if err != nil {
+			t.Fatalf("%s: Collect: %s", test.Name, err)
+		}
+
+		flushes, _ := histogramCountSum(t, rm, "http.server.stream.flush.duration")
+		if flushes != test.ExpectedFlushes {
+			t.Errorf("%s: expected %d recorded flushes; got %d", test.Name, test.ExpectedFlushes, flushes)
+		}
+
+		buffered, bytes := histogramCountSum(t, rm, "http.server.stream.buffered.size")
+		if buffered != test.ExpectedFlushes || bytes != test.ExpectedBytes {
+			t.Errorf(
+				"%s: expected %d buffered size records of %v bytes overall; got %d of %v bytes",
+				test.Name,
+				test.ExpectedFlushes,
+				test.ExpectedBytes,
+				buffered,
+				bytes,
+			)
+		}
+	}
+}
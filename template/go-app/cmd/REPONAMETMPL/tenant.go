@@ -0,0 +1,153 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+)
+
+// TenantHeader is the default request header carrying the tenant identifier.
+const TenantHeader = "X-Tenant-ID"
+
+var (
+	ErrNoTenant      = errors.New("no tenant in context")
+	ErrInvalidTenant = errors.New("invalid tenant identifier")
+)
+
+// tenantPattern restricts tenant identifiers to lowercase unquoted Postgres identifiers, leaving
+// room for schema prefix within the 63 bytes identifier limit.
+var tenantPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx holding tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant held by ctx, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+
+	return tenant, ok && tenant != ""
+}
+
+// ValidateTenant returns an error if tenant is not a valid tenant identifier.
+func ValidateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return fmt.Errorf("%q: %w", tenant, ErrInvalidTenant)
+	}
+
+	return nil
+}
+
+// NewTenantMiddleware returns a middleware that reads tenant identifier from header and stores it
+// in request context. Requests with a missing or invalid tenant are rejected with 400.
+func NewTenantMiddleware(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := r.Header.Get(header)
+
+			err := ValidateTenant(tenant)
+			if err != nil {
+				http.Error(
+					w,
+					http.StatusText(http.StatusBadRequest),
+					http.StatusBadRequest,
+				)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+		})
+	}
+}
+
+// TenantSchema returns the database schema of tenant, that is, tenant prefixed with schemaPrefix.
+func TenantSchema(tenant string, schemaPrefix string) (string, error) {
+	err := ValidateTenant(tenant)
+	if err != nil {
+		return "", err
+	}
+
+	return schemaPrefix + tenant, nil
+}
+
+// TxBeginner begins transactions, e.g. [pgxpool.Pool].
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// RunInTenantSchema runs fn in a transaction whose `search_path` is set to the schema of the tenant
+// held by ctx. The setting is transaction-local, so that pooled connections are not left pointing to
+// a tenant schema. Transaction is committed if fn succeeds, rolled back otherwise.
+func RunInTenantSchema(
+	ctx context.Context,
+	pool TxBeginner,
+	schemaPrefix string,
+	fn func(tx pgx.Tx) error,
+) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	schema, err := TenantSchema(tenant, schemaPrefix)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		// Identifier is both validated and quoted, and passed as a parameter rather than interpolated
+		_, err := tx.Exec(
+			ctx,
+			`SELECT set_config('search_path', $1, true)`,
+			pgx.Identifier{schema}.Sanitize(),
+		)
+		if err != nil {
+			return fmt.Errorf("error setting tenant search path: %w", err)
+		}
+
+		return fn(tx)
+	})
+}
+
+// NewExampleTenantDatabaseHandler queries the tasks table of the tenant schema.
+func NewExampleTenantDatabaseHandler(client *pgxpool.Pool, schemaPrefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var count int
+
+		err := RunInTenantSchema(r.Context(), client, schemaPrefix, func(tx pgx.Tx) error {
+			return tx.QueryRow(r.Context(), `SELECT count(*) FROM tasks`).Scan(&count)
+		})
+		if err != nil {
+			log.ErrLog(packageName, "error tenant database query", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+
+		type ExampleOutput struct {
+			Count int
+		}
+
+		resp.JSON(w, ExampleOutput{Count: count})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var gotTenant string
+
+	handler := NewTenantMiddleware(TenantHeader)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			gotTenant, _ = TenantFromContext(r.Context())
+		}),
+	)
+
+	tests := []struct {
+		Tenant         string
+		ExpectedStatus int
+	}{
+		{Tenant: "acme", ExpectedStatus: http.StatusOK},
+		{Tenant: "acme_2", ExpectedStatus: http.StatusOK},
+		{Tenant: "a" + strings.Repeat("b", 39), ExpectedStatus: http.StatusOK},
+		{Tenant: "", ExpectedStatus: http.StatusBadRequest},
+		{Tenant: "a;drop", ExpectedStatus: http.StatusBadRequest},
+		{Tenant: "A", ExpectedStatus: http.StatusBadRequest},
+		{Tenant: "1acme", ExpectedStatus: http.StatusBadRequest},
+		{Tenant: `acme"`, ExpectedStatus: http.StatusBadRequest},
+		{Tenant: strings.Repeat("a", 50), ExpectedStatus: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		gotTenant = ""
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(TenantHeader, test.Tenant)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%q: expected status %d; got %d", test.Tenant, test.ExpectedStatus, rr.Code)
+		}
+
+		if test.ExpectedStatus == http.StatusOK && gotTenant != test.Tenant {
+			t.Errorf("%q: expected tenant in context; got %q", test.Tenant, gotTenant)
+		}
+	}
+}
+
+func TestRunInTenantSchema(t *testing.T) {
+	t.Parallel()
+
+	db := &fakeDB{
+		row: func(_ string, _ []any) ([]any, error) {
+			return []any{3}, nil
+		},
+	}
+
+	var count int
+
+	err := RunInTenantSchema(
+		WithTenant(context.Background(), "acme"),
+		db,
+		"tenant_",
+		func(tx pgx.Tx) error {
+			return tx.QueryRow(context.Background(), `SELECT count(*) FROM tasks`).Scan(&count)
+		},
+	)
+	if err != nil {
+		t.Fatalf("RunInTenantSchema: %s", err)
+	}
+
+	expected := []string{
+		"BEGIN",
+		`SELECT set_config('search_path', $1, true)`,
+		`SELECT count(*) FROM tasks`,
+		"COMMIT",
+	}
+	if !slices.Equal(db.SQL(), expected) {
+		t.Errorf("expected statements %q; got %q", expected, db.SQL())
+	}
+
+	// Query runs after search path is set to the quoted tenant schema, within the same transaction
+	if schema := db.statements[1].Args[0]; schema != `"tenant_acme"` {
+		t.Errorf("expected search path %q; got %q", `"tenant_acme"`, schema)
+	}
+
+	if count != 3 {
+		t.Errorf("expected count %d; got %d", 3, count)
+	}
+}
+
+func TestRunInTenantSchemaRejected(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name          string
+		Ctx           context.Context
+		ExpectedError error
+	}{
+		{
+			Name:          "no tenant",
+			Ctx:           context.Background(),
+			ExpectedError: ErrNoTenant,
+		},
+		{
+			Name:          "invalid tenant",
+			Ctx:           WithTenant(context.Background(), "a;drop"),
+			ExpectedError: ErrInvalidTenant,
+		},
+	}
+
+	for _, test := range tests {
+		db := &fakeDB{}
+
+		err := RunInTenantSchema(test.Ctx, db, "tenant_", func(_ pgx.Tx) error {
+			return nil
+		})
+		if !errors.Is(err, test.ExpectedError) {
+			t.Errorf("%s: expected %s; got %v", test.Name, test.ExpectedError, err)
+		}
+
+		if len(db.SQL()) != 0 {
+			t.Errorf("%s: expected no statement; got %q", test.Name, db.SQL())
+		}
+	}
+}
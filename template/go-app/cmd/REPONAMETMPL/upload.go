@@ -0,0 +1,496 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/valkey-io/valkey-go"
+)
+
+// Resumable uploads follow the core of the [tus protocol].
+//
+// [tus protocol]: https://tus.io/protocols/resumable-upload
+const (
+	TusResumableHeader = "Tus-Resumable"
+	TusVersion         = "1.0.0"
+	UploadOffsetHeader = "Upload-Offset"
+	UploadLengthHeader = "Upload-Length"
+
+	MIMEApplicationOffsetOctetStream = "application/offset+octet-stream"
+)
+
+// uploadChunkSize is the size of parts request bodies are stored by, so that an interrupted request
+// still stores what it received.
+const uploadChunkSize = 32 * 1024
+
+// uploadLockTTL bounds how long an upload stays locked should the instance appending to it crash. It must
+// exceed the time an append request may last.
+const uploadLockTTL = 10 * time.Minute
+
+// uploadIDPattern matches upload identifiers, as generated by [crypto/rand.Text]. Validating them keeps
+// client input out of file paths.
+var uploadIDPattern = regexp.MustCompile(`^[A-Z2-7]{26}$`)
+
+// Advance result codes of uploadAdvanceScript.
+const (
+	uploadAdvanceNotFound       = -1
+	uploadAdvanceOffsetMismatch = -2
+	uploadAdvanceTooLarge       = -3
+)
+
+// uploadCreateScript atomically creates upload state, so that it can't be left without expiration.
+// KEYS: meta. ARGV: length, ttl in milliseconds.
+var uploadCreateScript = valkey.NewLuaScript(`
+redis.call('HSET', KEYS[1], 'offset', 0, 'length', ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// uploadAdvanceScript atomically advances offset of an upload by a stored chunk, provided client offset
+// matches stored one. KEYS: meta. ARGV: offset, chunk size, ttl in milliseconds. Returns new offset, or a
+// negative result code.
+var uploadAdvanceScript = valkey.NewLuaScript(`
+local meta = redis.call('HMGET', KEYS[1], 'offset', 'length')
+if not meta[1] then
+	return -1
+end
+if tonumber(meta[1]) ~= tonumber(ARGV[1]) then
+	return -2
+end
+if tonumber(meta[1]) + tonumber(ARGV[2]) > tonumber(meta[2]) then
+	return -3
+end
+local offset = redis.call('HINCRBY', KEYS[1], 'offset', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return offset
+`)
+
+// uploadUnlockScript releases an upload lock, provided it is still held by the given token.
+// KEYS: lock. ARGV: token.
+var uploadUnlockScript = valkey.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// UploadConfig defines the configuration for resumable upload handlers. Upload data is stored as files in
+// Dir, which is thus not bounded by cache memory, while cache only holds upload state (offset, length, lock)
+// so that it is shared across instances. Dir must then be shared across instances as well (e.g. a network
+// volume), or requests of an upload routed to the same instance.
+type UploadConfig struct {
+	// Directory holding upload data, which must exist
+	Dir string
+	// Maximum size of an upload
+	MaxSize int64
+	// How long incomplete uploads are kept after their last update
+	TTL time.Duration
+}
+
+// Hash tag keeps keys of an upload in the same cluster slot.
+func uploadMetaKey(id string) string {
+	return "upload:{" + id + "}:meta"
+}
+
+func uploadLockKey(id string) string {
+	return "upload:{" + id + "}:lock"
+}
+
+// UploadDataPath returns the path of the file holding data of upload id.
+func UploadDataPath(dir string, id string) string {
+	return filepath.Join(dir, id)
+}
+
+func uploadError(w http.ResponseWriter, code int) {
+	w.Header().Set(TusResumableHeader, TusVersion)
+	http.Error(w, http.StatusText(code), code)
+}
+
+// NewExampleUploadCreateHandler creates an upload of the length given in [UploadLengthHeader], returning its
+// location.
+func NewExampleUploadCreateHandler(client valkey.Client, conf UploadConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		length, err := strconv.ParseInt(r.Header.Get(UploadLengthHeader), 10, 64)
+		if err != nil || length < 0 {
+			uploadError(w, http.StatusBadRequest)
+
+			return
+		}
+
+		if length > conf.MaxSize {
+			uploadError(w, http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		id := rand.Text()
+
+		f, err := os.OpenFile(UploadDataPath(conf.Dir, id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			log.ErrLog(packageName, "error creating upload file", err)
+			uploadError(w, http.StatusInternalServerError)
+
+			return
+		}
+
+		err = f.Close()
+		if err == nil {
+			err = uploadCreateScript.Exec(
+				r.Context(),
+				client,
+				[]string{uploadMetaKey(id)},
+				[]string{strconv.FormatInt(length, 10), strconv.FormatInt(conf.TTL.Milliseconds(), 10)},
+			).Error()
+		}
+
+		if err != nil {
+			log.ErrLog(packageName, "error creating upload", err)
+			uploadError(w, http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set(TusResumableHeader, TusVersion)
+		w.Header().Set(headkey.Location, strings.TrimSuffix(r.URL.Path, "/")+"/"+id)
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// getUploadState returns offset and length of upload id, as stored in cache. ok is false if upload does not
+// exist.
+func getUploadState(ctx context.Context, client valkey.Client, id string) (offset, length int64, ok bool, err error) {
+	meta, err := client.Do(
+		ctx,
+		client.B().Hmget().Key(uploadMetaKey(id)).Field("offset", "length").Build(),
+	).ToArray()
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("error getting upload state: %w", err)
+	}
+
+	offset, err = meta[0].AsInt64()
+	if valkey.IsValkeyNil(err) {
+		return 0, 0, false, nil
+	}
+
+	if err == nil {
+		length, err = meta[1].AsInt64()
+	}
+
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("error parsing upload state: %w", err)
+	}
+
+	return offset, length, true, nil
+}
+
+// NewExampleUploadOffsetHandler returns the offset of an upload, that is, how many bytes were received, so
+// that client can resume it.
+func NewExampleUploadOffsetHandler(client valkey.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !uploadIDPattern.MatchString(id) {
+			uploadError(w, http.StatusNotFound)
+
+			return
+		}
+
+		offset, length, ok, err := getUploadState(r.Context(), client, id)
+		if err != nil {
+			log.ErrLog(packageName, "error getting upload offset", err)
+			uploadError(w, http.StatusInternalServerError)
+
+			return
+		}
+
+		if !ok {
+			uploadError(w, http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set(TusResumableHeader, TusVersion)
+		w.Header().Set(headkey.CacheControl, "no-store")
+		w.Header().Set(UploadOffsetHeader, strconv.FormatInt(offset, 10))
+		w.Header().Set(UploadLengthHeader, strconv.FormatInt(length, 10))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// NewExampleUploadAppendHandler appends request body to an upload at the offset given in [UploadOffsetHeader],
+// which must match current upload offset. Body is stored by chunks, so that an interrupted request can be
+// resumed from the last stored chunk. Concurrent appends to the same upload are rejected with 423. Completed
+// uploads are available at [UploadDataPath].
+func NewExampleUploadAppendHandler(client valkey.Client, conf UploadConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !headutil.IsMIME(r.Header, MIMEApplicationOffsetOctetStream) {
+			uploadError(w, http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		offset, err := strconv.ParseInt(r.Header.Get(UploadOffsetHeader), 10, 64)
+		if err != nil || offset < 0 {
+			uploadError(w, http.StatusBadRequest)
+
+			return
+		}
+
+		id := r.PathValue("id")
+		if !uploadIDPattern.MatchString(id) {
+			uploadError(w, http.StatusNotFound)
+
+			return
+		}
+
+		// Storing chunks must outlive the request, so that received data is kept for client to resume
+		ctx := context.WithoutCancel(r.Context())
+
+		token := rand.Text()
+
+		err = client.Do(
+			ctx,
+			client.B().Set().Key(uploadLockKey(id)).Value(token).Nx().Px(uploadLockTTL).Build(),
+		).Error()
+		if valkey.IsValkeyNil(err) {
+			uploadError(w, http.StatusLocked)
+
+			return
+		}
+
+		if err != nil {
+			log.ErrLog(packageName, "error locking upload", err)
+			uploadError(w, http.StatusInternalServerError)
+
+			return
+		}
+
+		defer func() {
+			err := uploadUnlockScript.Exec(ctx, client, []string{uploadLockKey(id)}, []string{token}).Error()
+			if err != nil {
+				log.ErrLog(packageName, "error unlocking upload", err)
+			}
+		}()
+
+		storedOffset, length, ok, err := getUploadState(ctx, client, id)
+		if err != nil {
+			log.ErrLog(packageName, "error getting upload offset", err)
+			uploadError(w, http.StatusInternalServerError)
+
+			return
+		}
+
+		if !ok {
+			uploadError(w, http.StatusNotFound)
+
+			return
+		}
+
+		if storedOffset != offset {
+			uploadError(w, http.StatusConflict)
+
+			return
+		}
+
+		f, err := os.OpenFile(UploadDataPath(conf.Dir, id), os.O_WRONLY, 0)
+		if errors.Is(err, fs.ErrNotExist) {
+			uploadError(w, http.StatusNotFound)
+
+			return
+		}
+
+		if err != nil {
+			log.ErrLog(packageName, "error opening upload file", err)
+			uploadError(w, http.StatusInternalServerError)
+
+			return
+		}
+		defer f.Close()
+
+		// Context deadline does not interrupt blocked body reads, connection read deadline does. Write deadline
+		// is extended as well, so that resulting offset is delivered despite server write timeout
+		if deadline, ok := r.Context().Deadline(); ok {
+			rc := http.NewResponseController(w)
+
+			err := rc.SetReadDeadline(deadline)
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				log.ErrLog(packageName, "error setting upload read deadline", err)
+			}
+
+			err = rc.SetWriteDeadline(deadline)
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				log.ErrLog(packageName, "error setting upload write deadline", err)
+			}
+		}
+
+		ttl := strconv.FormatInt(conf.TTL.Milliseconds(), 10)
+		buf := make([]byte, uploadChunkSize)
+
+		for {
+			n, readErr := io.ReadFull(r.Body, buf)
+
+			if n > 0 {
+				if offset+int64(n) > length {
+					uploadError(w, http.StatusRequestEntityTooLarge)
+
+					return
+				}
+
+				_, err := f.WriteAt(buf[:n], offset)
+				if err != nil {
+					log.ErrLog(packageName, "error writing upload chunk", err)
+					uploadError(w, http.StatusInternalServerError)
+
+					return
+				}
+
+				res, err := uploadAdvanceScript.Exec(
+					ctx,
+					client,
+					[]string{uploadMetaKey(id)},
+					[]string{strconv.FormatInt(offset, 10), strconv.Itoa(n), ttl},
+				).AsInt64()
+				if err != nil {
+					log.ErrLog(packageName, "error advancing upload offset", err)
+					uploadError(w, http.StatusInternalServerError)
+
+					return
+				}
+
+				switch res {
+				case uploadAdvanceNotFound:
+					uploadError(w, http.StatusNotFound)
+
+					return
+				case uploadAdvanceOffsetMismatch:
+					uploadError(w, http.StatusConflict)
+
+					return
+				case uploadAdvanceTooLarge:
+					uploadError(w, http.StatusRequestEntityTooLarge)
+
+					return
+				}
+
+				offset = res
+			}
+
+			if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+				break
+			}
+
+			if readErr != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(readErr, &maxBytesErr) {
+					uploadError(w, http.StatusRequestEntityTooLarge)
+
+					return
+				}
+
+				// Client went away, received chunks are kept for it to resume
+				log.ErrLog(packageName, "error reading upload chunk", readErr)
+				uploadError(w, http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		w.Header().Set(TusResumableHeader, TusVersion)
+		w.Header().Set(UploadOffsetHeader, strconv.FormatInt(offset, 10))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveStaleUploads removes files of conf.Dir not modified for longer than conf.TTL, that is, of uploads
+// whose state expired. Completed uploads are removed as well, and should thus be moved out of conf.Dir once
+// processed.
+func RemoveStaleUploads(conf UploadConfig) error {
+	entries, err := os.ReadDir(conf.Dir)
+	if err != nil {
+		return fmt.Errorf("error listing uploads: %w", err)
+	}
+
+	var errs []error
+
+	for _, entry := range entries {
+		if !uploadIDPattern.MatchString(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if time.Since(info.ModTime()) <= conf.TTL {
+			continue
+		}
+
+		err = os.Remove(UploadDataPath(conf.Dir, entry.Name()))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("error removing stale uploads: %w", err)
+	}
+
+	return nil
+}
+
+// WatchStaleUploads removes stale uploads every interval, see [RemoveStaleUploads]. It returns a function
+// stopping it.
+func WatchStaleUploads(conf UploadConfig, interval time.Duration) func() {
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := RemoveStaleUploads(conf)
+				if err != nil {
+					log.ErrLog(packageName, "error removing stale uploads", err)
+				}
+			}
+		}
+	})
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
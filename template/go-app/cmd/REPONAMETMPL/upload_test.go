@@ -0,0 +1,366 @@
+/*
+Copyright 2025 kemadev
+SPDX-License-Identifier: MPL-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+// interruptedReader returns data, then fails as if client went away.
+type interruptedReader struct {
+	data io.Reader
+}
+
+func (r *interruptedReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, io.ErrClosedPipe
+	}
+
+	return n, err
+}
+
+func newTestUploadHandler(t *testing.T, conf UploadConfig) (http.Handler, UploadConfig) {
+	t.Helper()
+
+	_, cacheClient := newFakeCache(t)
+
+	conf.Dir = t.TempDir()
+	conf.TTL = time.Hour
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /uploads/{$}", NewExampleUploadCreateHandler(cacheClient, conf))
+	mux.Handle("HEAD /uploads/{id}", NewExampleUploadOffsetHandler(cacheClient))
+	mux.Handle("PATCH /uploads/{id}", NewExampleUploadAppendHandler(cacheClient, conf))
+
+	return mux, conf
+}
+
+// createUpload creates an upload of length, returning its location.
+func createUpload(t *testing.T, handler http.Handler, length int) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/uploads/", nil)
+	req.Header.Set(UploadLengthHeader, strconv.Itoa(length))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: expected status %d; got %d", http.StatusCreated, rr.Code)
+	}
+
+	return rr.Header().Get(headkey.Location)
+}
+
+// uploadOffset returns the offset of upload at location.
+func uploadOffset(t *testing.T, handler http.Handler, location string) string {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, location, nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("offset: expected status %d; got %d", http.StatusOK, rr.Code)
+	}
+
+	return rr.Header().Get(UploadOffsetHeader)
+}
+
+func appendUpload(handler http.Handler, location string, offset int, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, location, body)
+	req.Header.Set(headkey.ContentType, MIMEApplicationOffsetOctetStream)
+	req.Header.Set(UploadOffsetHeader, strconv.Itoa(offset))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestUploadResume(t *testing.T) {
+	t.Parallel()
+
+	handler, conf := newTestUploadHandler(t, UploadConfig{MaxSize: 1 << 20})
+
+	data := make([]byte, 100*1024)
+	_, _ = rand.Read(data)
+
+	location := createUpload(t, handler, len(data))
+
+	if offset := uploadOffset(t, handler, location); offset != "0" {
+		t.Errorf("expected initial offset %q; got %q", "0", offset)
+	}
+
+	// Client goes away after sending part of the body, received part is kept
+	interruptedAt := 40 * 1024
+
+	rr := appendUpload(handler, location, 0, &interruptedReader{data: bytes.NewReader(data[:interruptedAt])})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("interrupted append: expected status %d; got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	if offset := uploadOffset(t, handler, location); offset != strconv.Itoa(interruptedAt) {
+		t.Errorf("expected offset %d after interruption; got %q", interruptedAt, offset)
+	}
+
+	// Resuming from a stale offset is rejected
+	rr = appendUpload(handler, location, 0, bytes.NewReader(data))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("stale append: expected status %d; got %d", http.StatusConflict, rr.Code)
+	}
+
+	rr = appendUpload(handler, location, interruptedAt, bytes.NewReader(data[interruptedAt:]))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("resumed append: expected status %d; got %d", http.StatusNoContent, rr.Code)
+	}
+
+	if offset := rr.Header().Get(UploadOffsetHeader); offset != strconv.Itoa(len(data)) {
+		t.Errorf("expected offset %d once complete; got %q", len(data), offset)
+	}
+
+	stored, err := os.ReadFile(UploadDataPath(conf.Dir, path.Base(location)))
+	if err != nil {
+		t.Fatalf("ReadFile: %s", err)
+	}
+
+	if !bytes.Equal(stored, data) {
+		t.Errorf("expected stored upload to match sent data")
+	}
+
+	// Complete upload can't grow
+	rr = appendUpload(handler, location, len(data), bytes.NewReader([]byte("x")))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("append to complete upload: expected status %d; got %d", http.StatusRequestEntityTooLarge, rr.Code)
+	}
+}
+
+func TestUploadOutlivesWriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	handler, _ := newTestUploadHandler(t, UploadConfig{MaxSize: 1 << 20})
+
+	// Deadline is set on context only, so that handler is the one extending connection deadlines
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}))
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	client := srv.Client()
+	client.Timeout = 5 * time.Second
+
+	const chunks, chunkSize = 4, 1024
+
+	location := createUpload(t, handler, chunks*chunkSize)
+
+	// Stream body for longer than server write timeout
+	body, bodyWriter := io.Pipe()
+
+	go func() {
+		for range chunks {
+			time.Sleep(100 * time.Millisecond)
+
+			_, err := bodyWriter.Write(make([]byte, chunkSize))
+			if err != nil {
+				return
+			}
+		}
+
+		_ = bodyWriter.Close()
+	}()
+
+	req, err := http.NewRequest(http.MethodPatch, srv.URL+location, body)
+	if err != nil {
+		t.Fatalf("NewRequest: %s", err)
+	}
+
+	req.Header.Set(headkey.ContentType, MIMEApplicationOffsetOctetStream)
+	req.Header.Set(UploadOffsetHeader, "0")
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected response to be delivered; got %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d; got %d", http.StatusNoContent, res.StatusCode)
+	}
+
+	if offset := res.Header.Get(UploadOffsetHeader); offset != strconv.Itoa(chunks*chunkSize) {
+		t.Errorf("expected offset %d; got %q", chunks*chunkSize, offset)
+	}
+}
+
+func TestUploadRejected(t *testing.T) {
+	t.Parallel()
+
+	_, cacheClient := newFakeCache(t)
+
+	conf := UploadConfig{Dir: t.TempDir(), MaxSize: 10, TTL: time.Hour}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /uploads/{$}", NewExampleUploadCreateHandler(cacheClient, conf))
+	mux.Handle("PATCH /uploads/{id}", NewExampleUploadAppendHandler(cacheClient, conf))
+
+	location := createUpload(t, mux, 5)
+
+	// Another append to this upload is in progress
+	locked := createUpload(t, mux, 5)
+
+	err := cacheClient.Do(
+		t.Context(),
+		cacheClient.B().Set().Key(uploadLockKey(path.Base(locked))).Value("other").Build(),
+	).Error()
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Location       string
+		Length         string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "too large",
+			Method:         http.MethodPost,
+			Location:       "/uploads/",
+			Length:         "11",
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:           "invalid length",
+			Method:         http.MethodPost,
+			Location:       "/uploads/",
+			Length:         "-1",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "body beyond length",
+			Method:         http.MethodPatch,
+			Location:       location,
+			Body:           "123456",
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:           "unknown upload",
+			Method:         http.MethodPatch,
+			Location:       "/uploads/" + rand.Text(),
+			Body:           "1",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "invalid identifier",
+			Method:         http.MethodPatch,
+			Location:       "/uploads/..%2Fescape",
+			Body:           "1",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "not an identifier",
+			Method:         http.MethodPatch,
+			Location:       "/uploads/abc",
+			Body:           "1",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "locked",
+			Method:         http.MethodPatch,
+			Location:       locked,
+			Body:           "1",
+			ExpectedStatus: http.StatusLocked,
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.Method, test.Location, bytes.NewReader([]byte(test.Body)))
+		req.Header.Set(UploadLengthHeader, test.Length)
+		req.Header.Set(headkey.ContentType, MIMEApplicationOffsetOctetStream)
+		req.Header.Set(UploadOffsetHeader, "0")
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+	}
+
+	// Rejected appends store nothing, and release their lock
+	rr := appendUpload(mux, location, 0, bytes.NewReader([]byte("12345")))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("append after rejection: expected status %d; got %d", http.StatusNoContent, rr.Code)
+	}
+}
+
+func TestRemoveStaleUploads(t *testing.T) {
+	t.Parallel()
+
+	conf := UploadConfig{Dir: t.TempDir(), TTL: time.Hour}
+
+	stale := rand.Text()
+	fresh := rand.Text()
+	other := "keep.txt"
+
+	for _, name := range []string{stale, fresh, other} {
+		err := os.WriteFile(UploadDataPath(conf.Dir, name), nil, 0o600)
+		if err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+
+	for _, name := range []string{stale, other} {
+		err := os.Chtimes(UploadDataPath(conf.Dir, name), old, old)
+		if err != nil {
+			t.Fatalf("Chtimes: %s", err)
+		}
+	}
+
+	err := RemoveStaleUploads(conf)
+	if err != nil {
+		t.Fatalf("RemoveStaleUploads: %s", err)
+	}
+
+	tests := []struct {
+		Name           string
+		ExpectedExists bool
+	}{
+		{Name: stale, ExpectedExists: false},
+		{Name: fresh, ExpectedExists: true},
+		{Name: other, ExpectedExists: true},
+	}
+
+	for _, test := range tests {
+		_, err := os.Stat(UploadDataPath(conf.Dir, test.Name))
+		if exists := !errors.Is(err, fs.ErrNotExist); exists != test.ExpectedExists {
+			t.Errorf("%s: expected exists %t; got %t", test.Name, test.ExpectedExists, exists)
+		}
+	}
+}